@@ -0,0 +1,61 @@
+// Command gocommon provides the tooling of go-common:
+//
+//	gocommon slo rules -config config.yaml [-out rules.yaml]
+//
+// generates the Prometheus recording and alerting rules of the objectives of slo.objectives
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nmtri1912/go-common/pkg/slo"
+	"github.com/spf13/viper"
+)
+
+const usage = "usage: gocommon slo rules -config <file> [-out <file>] [-application <name>]"
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "slo" || os.Args[2] != "rules" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := sloRules(os.Args[3:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func sloRules(args []string) error {
+	flags := flag.NewFlagSet("slo rules", flag.ExitOnError)
+	configFile := flags.String("config", "", "config file declaring slo.objectives")
+	out := flags.String("out", "", "rules file, stdout if empty")
+	application := flags.String("application", "", "application label of the metrics, service.name of the config if empty")
+	flags.Parse(args)
+	if len(*configFile) == 0 {
+		return errors.New(usage)
+	}
+
+	viper.SetConfigFile(*configFile)
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	config, err := slo.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if len(*application) == 0 {
+		*application = viper.GetString("service.name")
+	}
+	rules, err := slo.GenerateRules(config, *application)
+	if err != nil {
+		return err
+	}
+	if len(*out) == 0 {
+		_, err = os.Stdout.Write(rules)
+		return err
+	}
+	return os.WriteFile(*out, rules, 0644)
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+var (
+	changeMutex    sync.Mutex
+	changeHandlers []func(event fsnotify.Event)
+)
+
+// OnConfigChange adds handler to the functions called when the config file changes. Unlike
+// viper.OnConfigChange, which keeps a single function, the handlers of the modules and of the app
+// are all called. The config file is watched from the first call
+func OnConfigChange(handler func(event fsnotify.Event)) {
+	changeMutex.Lock()
+	defer changeMutex.Unlock()
+	changeHandlers = append(changeHandlers, handler)
+	if len(changeHandlers) == 1 {
+		viper.OnConfigChange(configChanged)
+		viper.WatchConfig()
+	}
+}
+
+func configChanged(event fsnotify.Event) {
+	changeMutex.Lock()
+	handlers := changeHandlers
+	changeMutex.Unlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
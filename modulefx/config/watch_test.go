@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+func TestOnConfigChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("logger:\n  level: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := make(chan string, 10), make(chan string, 10)
+	OnConfigChange(func(event fsnotify.Event) { first <- viper.GetString("logger.level") })
+	OnConfigChange(func(event fsnotify.Event) { second <- viper.GetString("logger.level") })
+
+	if err := os.WriteFile(path, []byte("logger:\n  level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, changes := range map[string]chan string{"first": first, "second": second} {
+		select {
+		case level := <-changes:
+			if level != "debug" {
+				t.Errorf("%s handler: level = %s, want the reloaded debug", name, level)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("%s handler is not called", name)
+		}
+	}
+}
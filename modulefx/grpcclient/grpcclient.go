@@ -0,0 +1,112 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/nmtri1912/go-common/pkg/discovery"
+	"github.com/nmtri1912/go-common/pkg/redis"
+	"github.com/nmtri1912/go-common/utils/grpcutils"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+// Clients holds the connections of services listed in grpc.clients, each service is configured by <service>.*
+type Clients struct {
+	conns map[string]*grpc.ClientConn
+}
+
+type ClientsParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	// Cache is required by services using the redis resolver
+	Cache redis.Cache `optional:"true"`
+}
+
+func NewClients(params ClientsParams) (*Clients, error) {
+	clients := &Clients{conns: map[string]*grpc.ClientConn{}}
+	for _, service := range viper.GetStringSlice("grpc.clients") {
+		conn, err := dial(service, registryDialOptions(params.Cache, service)...)
+		if err != nil {
+			// the app doesn't start, so OnStop would never close the connections already created
+			if closeErr := clients.close(); closeErr != nil {
+				log.Println("Close grpc connections has error:", closeErr.Error())
+			}
+			return nil, err
+		}
+		clients.conns[service] = conn
+	}
+	params.Lifecycle.Append(fx.Hook{OnStop: func(ctx context.Context) error {
+		return clients.close()
+	}})
+	return clients, nil
+}
+
+func (c *Clients) close() error {
+	var closeErr error
+	for service, conn := range c.conns {
+		log.Println("Closing grpc connection", service)
+		if err := conn.Close(); err != nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// Get returns the connection of service
+func (c *Clients) Get(service string) (*grpc.ClientConn, error) {
+	conn, exist := c.conns[service]
+	if !exist {
+		return nil, fmt.Errorf("grpc client %s is not configured in grpc.clients", service)
+	}
+	return conn, nil
+}
+
+// NewConnection creates a connection to service, which is closed when the app stops
+func NewConnection(lifecycle fx.Lifecycle, service string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	conn, err := dial(service, opts...)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Append(fx.Hook{OnStop: func(ctx context.Context) error {
+		log.Println("Closing grpc connection", service)
+		return conn.Close()
+	}})
+	return conn, nil
+}
+
+func dial(service string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	conn, err := grpcutils.NewConnection(context.Background(), service, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("fail to dial %s: %w", service, err)
+	}
+	log.Println("Init grpc connection success", service, conn.Target())
+	return conn, nil
+}
+
+// Provide provides a *grpc.ClientConn named `name:"<service>"` for each service
+func Provide(services ...string) fx.Option {
+	options := make([]fx.Option, 0, len(services))
+	for _, service := range services {
+		service := service
+		options = append(options, fx.Provide(fx.Annotated{
+			Name: service,
+			Target: func(params ClientsParams) (*grpc.ClientConn, error) {
+				return NewConnection(params.Lifecycle, service, registryDialOptions(params.Cache, service)...)
+			},
+		}))
+	}
+	return fx.Options(options...)
+}
+
+// registryDialOptions adds the redis resolver, used by services with <service>.discovery.resolver = redis
+func registryDialOptions(cache redis.Cache, service string) []grpc.DialOption {
+	if cache == nil {
+		return nil
+	}
+	registry := discovery.NewRegistry(cache, discovery.RegistryTTL())
+	return []grpc.DialOption{grpc.WithResolvers(discovery.NewRedisBuilder(registry, discovery.RefreshInterval(service)))}
+}
@@ -0,0 +1,5 @@
+package grpcclient
+
+import "go.uber.org/fx"
+
+var Module = fx.Provide(NewClients)
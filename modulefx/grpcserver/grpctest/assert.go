@@ -0,0 +1,93 @@
+package grpctest
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// AssertLogged fails t when no entry of message at level with the given field values was captured
+func (h *Harness) AssertLogged(t testing.TB, level zapcore.Level, message string, fields map[string]interface{}) {
+	t.Helper()
+	h.Logs.AssertLogged(t, level, message, fields)
+}
+
+// FindSpan returns the first ended span named name, nil if none
+func (h *Harness) FindSpan(name string) sdktrace.ReadOnlySpan {
+	for _, span := range h.Spans.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// AssertSpan fails t when no span named name has ended, the span is returned otherwise
+func (h *Harness) AssertSpan(t testing.TB, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	span := h.FindSpan(name)
+	if span == nil {
+		names := make([]string, 0, len(h.Spans.Ended()))
+		for _, ended := range h.Spans.Ended() {
+			names = append(names, ended.Name())
+		}
+		t.Errorf("no span %q, ended spans: %v", name, names)
+	}
+	return span
+}
+
+// Metric returns the sample of the metric family name whose labels contain labels,
+// read from prometheus.DefaultGatherer. Nil if not found
+func Metric(t testing.TB, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	return GatheredMetric(t, prometheus.DefaultGatherer, name, labels)
+}
+
+// GatheredMetric is Metric read from gatherer, e.g. a registry created for the test
+func GatheredMetric(t testing.TB, gatherer prometheus.Gatherer, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatalf("fail to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if hasLabels(metric, labels) {
+				return metric
+			}
+		}
+	}
+	return nil
+}
+
+// CounterValue returns the value of a counter sample, 0 if not found.
+// Metrics are process wide, compare values before and after the call under test
+func CounterValue(t testing.TB, name string, labels map[string]string) float64 {
+	t.Helper()
+	return Metric(t, name, labels).GetCounter().GetValue()
+}
+
+// GaugeValue returns the value of a gauge sample, 0 if not found
+func GaugeValue(t testing.TB, name string, labels map[string]string) float64 {
+	t.Helper()
+	return Metric(t, name, labels).GetGauge().GetValue()
+}
+
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	matched := 0
+	for _, pair := range metric.GetLabel() {
+		if value, exist := labels[pair.GetName()]; exist {
+			if value != pair.GetValue() {
+				return false
+			}
+			matched++
+		}
+	}
+	return matched == len(labels)
+}
@@ -0,0 +1,138 @@
+package grpctest
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/nmtri1912/go-common/modulefx/grpcserver"
+	grpc_util "github.com/nmtri1912/go-common/pkg/grpc"
+	"github.com/nmtri1912/go-common/pkg/health"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// Harness runs the full go-common server stack of a GrpcService on an in-memory listener.
+// It owns its health checker and tracer provider, the global ones are not modified
+type Harness struct {
+	// Conn is authenticated with the first client (sorted by id) of GrpcService.Clients
+	Conn   *grpc.ClientConn
+	Server *grpcserver.Server
+	// Logs captures the entries written with logger.Ctx of the requests, and the other entries
+	// of logger.L() and logger.Ctx() written during the test
+	Logs *logger.TestLogger
+	// Spans captures the server spans and their children started with trace.SpanFromContext(ctx).TracerProvider()
+	Spans *tracetest.SpanRecorder
+	// Health holds the dependency checks reported by the health server, register the checks of the test in it
+	Health *health.Checker
+
+	listener *bufconn.Listener
+}
+
+// NewHarness starts service on bufconn, everything is stopped on t.Cleanup.
+// The server reads the grpc.* config, set it with viper.Set before calling NewHarness.
+// The logs of the requests are only captured by the harness of t, t can run in parallel
+func NewHarness(t testing.TB, service *grpcserver.GrpcService) *Harness {
+	t.Helper()
+	logs := logger.NewTestLogger(t)
+
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	t.Cleanup(func() {
+		_ = tracerProvider.Shutdown(context.Background())
+	})
+	checker := health.NewChecker()
+
+	server, err := grpcserver.NewServer(service,
+		grpcserver.WithHealthChecker(checker),
+		grpcserver.WithTracerProvider(tracerProvider),
+		grpcserver.WithOuterInterceptors(
+			[]grpc.UnaryServerInterceptor{testLoggerUnaryInterceptor(logs)},
+			[]grpc.StreamServerInterceptor{testLoggerStreamInterceptor(logs)},
+		))
+	if err != nil {
+		t.Fatalf("fail to create grpc server: %v", err)
+	}
+	harness := &Harness{
+		Server:   server,
+		Logs:     logs,
+		Spans:    spans,
+		Health:   checker,
+		listener: bufconn.Listen(bufSize),
+	}
+	server.Start(harness.listener)
+	t.Cleanup(server.Stop)
+
+	var opts []grpc.DialOption
+	if clientId, clientKey, ok := firstClient(service.Clients); ok {
+		opts = append(opts, grpc.WithPerRPCCredentials(grpc_util.NewClientKeyCredentials(clientId, clientKey, false)))
+	}
+	harness.Conn = harness.Dial(t, opts...)
+	return harness
+}
+
+// Dial opens another connection to the server, without credentials unless given in opts
+func (h *Harness) Dial(t testing.TB, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.DialContext(context.Background(), bufTarget, append(dialOptions(h.listener), opts...)...)
+	if err != nil {
+		t.Fatalf("fail to dial grpc server: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+// testLoggerUnaryInterceptor routes the logs written with logger.Ctx of the requests to logs
+func testLoggerUnaryInterceptor(logs *logger.TestLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(logs.Context(ctx), req)
+	}
+}
+
+func testLoggerStreamInterceptor(logs *logger.TestLogger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: logs.Context(ss.Context())})
+	}
+}
+
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func firstClient(clients map[string]string) (string, string, bool) {
+	ids := make([]string, 0, len(clients))
+	for id := range clients {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return "", "", false
+	}
+	sort.Strings(ids)
+	return ids[0], clients[ids[0]], true
+}
+
+// bufTarget is resolved by the context dialer, passthrough skips DNS resolution
+const bufTarget = "passthrough:///bufnet"
+
+func dialOptions(listener *bufconn.Listener) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+}
@@ -0,0 +1,103 @@
+package grpctest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/nmtri1912/go-common/modulefx/grpcserver"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const echoMethod = "/test.Echo/Echo"
+
+// echoService answers the request, within a child span of the server span
+var echoService = &grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := &wrapperspb.StringValue{}
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("test").Start(ctx, "echo")
+				defer span.End()
+				return req, nil
+			}
+			return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: echoMethod}, handler)
+		},
+	}},
+}
+
+func newEchoHarness(t *testing.T) *Harness {
+	return NewHarness(t, &grpcserver.GrpcService{
+		ServiceDesc: echoService,
+		ServiceImpl: struct{}{},
+		Clients:     map[string]string{"client": "key"},
+	})
+}
+
+func TestHarnessSpans(t *testing.T) {
+	t.Parallel()
+	h := newEchoHarness(t)
+	out := &wrapperspb.StringValue{}
+	if err := h.Conn.Invoke(context.Background(), echoMethod, wrapperspb.String("hi"), out); err != nil || out.Value != "hi" {
+		t.Fatalf("Echo = %q, %v", out.Value, err)
+	}
+	server := h.AssertSpan(t, "test.Echo/Echo")
+	child := h.AssertSpan(t, "echo")
+	if server != nil && child != nil && child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("the handler span should be a child of the server span")
+	}
+}
+
+func TestHarnessHealthIsolated(t *testing.T) {
+	t.Parallel()
+	stopped := newEchoHarness(t)
+	stopped.Server.Stop()
+
+	h := newEchoHarness(t)
+	resp, err := healthpb.NewHealthClient(h.Conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: echoService.ServiceName})
+	if err != nil {
+		t.Fatalf("health check: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("status = %v after other harnesses stopped, want SERVING", resp.Status)
+	}
+}
+
+func TestHarnessLogsClientId(t *testing.T) {
+	t.Parallel()
+	h := newEchoHarness(t)
+	if err := h.Conn.Invoke(context.Background(), echoMethod, wrapperspb.String("hi"), &wrapperspb.StringValue{}); err != nil {
+		t.Fatalf("Echo: %v", err)
+	}
+	h.AssertLogged(t, zapcore.InfoLevel, "Request: ", map[string]interface{}{logger.FieldClientId: "client"})
+	h.AssertLogged(t, zapcore.InfoLevel, "Response: ", map[string]interface{}{logger.FieldClientId: "client"})
+}
+
+func TestHarnessLogsIsolated(t *testing.T) {
+	for calls := 1; calls <= 3; calls++ {
+		calls := calls
+		t.Run(strconv.Itoa(calls), func(t *testing.T) {
+			t.Parallel()
+			h := newEchoHarness(t)
+			for i := 0; i < calls; i++ {
+				if err := h.Conn.Invoke(context.Background(), echoMethod, wrapperspb.String("hi"), &wrapperspb.StringValue{}); err != nil {
+					t.Fatalf("Echo: %v", err)
+				}
+			}
+			if got := len(h.Logs.ByMessage("Request: ")); got != calls {
+				t.Errorf("captured %d requests, want the %d of this test", got, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package grpctest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// StubFunc answers a stubbed method, req is the decoded request
+type StubFunc func(ctx context.Context, req interface{}) (interface{}, error)
+
+// Call is a request received by an Upstream
+type Call struct {
+	Method   string
+	Metadata metadata.MD
+	Request  interface{}
+}
+
+// UpstreamBuilder builds a fake dependency, e.g.
+//
+//	upstream := grpctest.NewUpstream().
+//		WithService(&pb.User_ServiceDesc, pb.UnimplementedUserServer{}).
+//		Stub("/pb.User/Get", func(ctx context.Context, req interface{}) (interface{}, error) {
+//			return &pb.GetResponse{Name: "test"}, nil
+//		}).
+//		Start(t)
+//	upstream.Configure("user-service")
+type UpstreamBuilder struct {
+	services []upstreamService
+	stubs    map[string]StubFunc
+}
+
+type upstreamService struct {
+	desc *grpc.ServiceDesc
+	impl interface{}
+}
+
+func NewUpstream() *UpstreamBuilder {
+	return &UpstreamBuilder{stubs: map[string]StubFunc{}}
+}
+
+// WithService registers impl, use the generated Unimplemented<Service>Server to stub methods one by one
+func (b *UpstreamBuilder) WithService(desc *grpc.ServiceDesc, impl interface{}) *UpstreamBuilder {
+	b.services = append(b.services, upstreamService{desc: desc, impl: impl})
+	return b
+}
+
+// Stub answers the unary method fullMethod with stub instead of the registered implementation
+func (b *UpstreamBuilder) Stub(fullMethod string, stub StubFunc) *UpstreamBuilder {
+	b.stubs[fullMethod] = stub
+	return b
+}
+
+// Start serves the upstream on bufconn until t ends
+func (b *UpstreamBuilder) Start(t testing.TB) *Upstream {
+	t.Helper()
+	upstream := &Upstream{listener: bufconn.Listen(bufSize)}
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(upstream.record, stubInterceptor(b.stubs)))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	for _, service := range b.services {
+		server.RegisterService(service.desc, service.impl)
+	}
+	go func() {
+		_ = server.Serve(upstream.listener)
+	}()
+	t.Cleanup(server.Stop)
+	return upstream
+}
+
+func stubInterceptor(stubs map[string]StubFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if stub, exist := stubs[info.FullMethod]; exist {
+			return stub(ctx, req)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Upstream is a running fake dependency
+type Upstream struct {
+	listener *bufconn.Listener
+	mutex    sync.Mutex
+	calls    []Call
+}
+
+func (u *Upstream) record(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	requestMetadata, _ := metadata.FromIncomingContext(ctx)
+	u.mutex.Lock()
+	u.calls = append(u.calls, Call{Method: info.FullMethod, Metadata: requestMetadata, Request: req})
+	u.mutex.Unlock()
+	return handler(ctx, req)
+}
+
+// Target is the dial target of the upstream, it is only reachable with DialOptions
+func (u *Upstream) Target() string {
+	return bufTarget
+}
+
+// DialOptions connect to the upstream, to be given to grpcutils.NewConnection or grpcclient.NewConnection
+func (u *Upstream) DialOptions() []grpc.DialOption {
+	return dialOptions(u.listener)
+}
+
+// Configure points <service>.target to the upstream, so that
+// grpcutils.NewConnection(ctx, service, upstream.DialOptions()...) reaches it
+func (u *Upstream) Configure(service string) {
+	viper.Set(service+".target", u.Target())
+	viper.Set(service+".discovery.resolver", "")
+}
+
+// Dial opens a plain connection to the upstream
+func (u *Upstream) Dial(t testing.TB) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.DialContext(context.Background(), bufTarget, u.DialOptions()...)
+	if err != nil {
+		t.Fatalf("fail to dial upstream: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+// Calls returns the requests received for fullMethod, all requests if empty
+func (u *Upstream) Calls(fullMethod string) []Call {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	calls := make([]Call, 0, len(u.calls))
+	for _, call := range u.calls {
+		if len(fullMethod) == 0 || call.Method == fullMethod {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
@@ -6,4 +6,5 @@ import (
 
 var Module = fx.Options(
 	fx.Invoke(StartGrpcServer),
+	fx.Invoke(RegisterInstance),
 )
@@ -0,0 +1,54 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nmtri1912/go-common/pkg/discovery"
+	"github.com/nmtri1912/go-common/pkg/redis"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+)
+
+type RegistryParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Cache     redis.Cache `optional:"true"`
+}
+
+// RegisterInstance registers the server as an instance of service.name in the Redis registry when
+// grpc.registry.enabled is set, so that clients using the redis resolver can reach it
+func RegisterInstance(params RegistryParams) error {
+	if !viper.GetBool("grpc.registry.enabled") {
+		return nil
+	}
+	if params.Cache == nil {
+		return errors.New("grpc.registry.enabled requires the redis module")
+	}
+	service := viper.GetString("service.name")
+	address := viper.GetString("grpc.registry.address")
+	if len(address) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		address = fmt.Sprintf("%s:%d", hostname, viper.GetInt("grpc.port"))
+	}
+
+	registry := discovery.NewRegistry(params.Cache, discovery.RegistryTTL())
+	var stop func()
+	params.Lifecycle.Append(fx.Hook{OnStart: func(ctx context.Context) error {
+		log.Println("Registering gRPC server", service, address)
+		stop = registry.Heartbeat(service, address)
+		return nil
+	}, OnStop: func(ctx context.Context) error {
+		log.Println("Deregistering gRPC server", service, address)
+		stop()
+		return nil
+	}})
+	return nil
+}
@@ -5,14 +5,22 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"strings"
+	"time"
 
 	grpc_util "github.com/nmtri1912/go-common/pkg/grpc"
+	health_util "github.com/nmtri1912/go-common/pkg/health"
+	"github.com/nmtri1912/go-common/pkg/propagation"
 
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/admin"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 type GrpcService struct {
@@ -20,41 +28,201 @@ type GrpcService struct {
 	ServiceImpl          interface{}
 	Clients              map[string]string
 	AllowedMethodClients map[string][]string
+	// Dependencies are the health checks (e.g. mysql, redis, kafka) the service depends on, empty means all
+	Dependencies []string
+	// UnaryInterceptors are chained after the built-in interceptors, e.g. idempotency.NewUnaryServerInterceptor
+	UnaryInterceptors []grpc.UnaryServerInterceptor
+	// ProtoValidator is optional, used to validate requests with protovalidate-style constraints
+	ProtoValidator grpc_util.ProtoValidateFunc
 }
 
-func StartGrpcServer(lifecycle fx.Lifecycle, service *GrpcService) error {
-	port := viper.GetInt("grpc.port")
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
-	if err != nil {
-		return err
+// Server is a gRPC server with the go-common interceptors, health, admin services and the app service registered
+type Server struct {
+	*grpc.Server
+	healthReporter *health_util.GrpcReporter
+	cleanupAdmin   func()
+}
+
+// ServerOption overrides the process-wide dependencies of a Server, e.g. to run several servers in tests
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	checker            *health_util.Checker
+	tracerProvider     trace.TracerProvider
+	unaryInterceptors  []grpc.UnaryServerInterceptor
+	streamInterceptors []grpc.StreamServerInterceptor
+}
+
+// WithHealthChecker reports the checks of checker instead of health.DefaultChecker
+func WithHealthChecker(checker *health_util.Checker) ServerOption {
+	return func(options *serverOptions) {
+		options.checker = checker
+	}
+}
+
+// WithTracerProvider starts the server spans with provider instead of the global one
+func WithTracerProvider(provider trace.TracerProvider) ServerOption {
+	return func(options *serverOptions) {
+		options.tracerProvider = provider
 	}
+}
+
+// WithOuterInterceptors runs interceptors before the go-common ones, e.g. to set up the context of the requests
+func WithOuterInterceptors(unary []grpc.UnaryServerInterceptor, stream []grpc.StreamServerInterceptor) ServerOption {
+	return func(options *serverOptions) {
+		options.unaryInterceptors = append(options.unaryInterceptors, unary...)
+		options.streamInterceptors = append(options.streamInterceptors, stream...)
+	}
+}
+
+// NewServer builds the server of service from the grpc.* config, without listening
+func NewServer(service *GrpcService, opts ...ServerOption) (*Server, error) {
+	options := &serverOptions{checker: health_util.DefaultChecker}
+	for _, opt := range opts {
+		opt(options)
+	}
+	var tracingOptions []grpc_util.TracingOption
+	if options.tracerProvider != nil {
+		tracingOptions = append(tracingOptions, grpc_util.WithTracerProvider(options.tracerProvider))
+	}
+	domain := viper.GetString("service.name")
+	defaultTimeout := time.Duration(viper.GetInt64("grpc.default-timeout-ms")) * time.Millisecond
+	methodTimeouts := getMethodTimeouts("grpc.method-timeouts-ms")
+	propagationKeys := propagation.Keys()
+	// copy to add admin methods without touching the service's map
+	methodClients := make(map[string][]string, len(service.AllowedMethodClients))
+	for method, clients := range service.AllowedMethodClients {
+		methodClients[method] = clients
+	}
+	// filled by registerAdminServices before serving
+	adminMethods := map[string]bool{}
 	grpcServer := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
+		grpc.ChainUnaryInterceptor(append(append(options.unaryInterceptors,
 			grpc_util.NewRecoverUnaryServerInterceptor(),
-			grpc_util.NewTracingUnaryServerInterceptor(),
+			grpc_util.NewTracingUnaryServerInterceptor(tracingOptions...),
+			propagation.NewUnaryServerInterceptor(propagationKeys),
+			grpc_util.NewTimeoutUnaryServerInterceptor(defaultTimeout, methodTimeouts),
+			// before logging, so that the Request and Response logs have the client_id
+			grpc_util.NewAuthenUnaryServerInterceptor(service.Clients, methodClients),
 			grpc_util.NewLoggingUnaryServerInterceptor(),
-			grpc_util.NewAuthenUnaryServerInterceptor(service.Clients, service.AllowedMethodClients),
-		),
+			grpc_util.NewValidationUnaryServerInterceptor(domain, service.ProtoValidator),
+		), service.UnaryInterceptors...)...),
+		grpc.ChainStreamInterceptor(append(options.streamInterceptors,
+			propagation.NewStreamServerInterceptor(propagationKeys),
+			grpc_util.NewTimeoutStreamServerInterceptor(defaultTimeout, methodTimeouts),
+			newAdminAuthenStreamServerInterceptor(service.Clients, methodClients, adminMethods),
+			grpc_util.NewValidationStreamServerInterceptor(domain, service.ProtoValidator),
+		)...),
 	)
 	//health check
-	grpc_health_v1.RegisterHealthServer(grpcServer, health.NewServer())
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	healthReporter := health_util.NewGrpcReporter(options.checker, healthServer, health_util.Interval(), health_util.Timeout())
+	healthReporter.AddService(service.ServiceDesc.ServiceName, service.Dependencies...)
 	//actual service
 	grpcServer.RegisterService(service.ServiceDesc, service.ServiceImpl)
+	//debugging services
+	cleanupAdmin, err := registerAdminServices(grpcServer, methodClients, adminMethods)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		Server:         grpcServer,
+		healthReporter: healthReporter,
+		cleanupAdmin:   cleanupAdmin,
+	}, nil
+}
+
+// Start starts the health reporter and serves lis in background
+func (s *Server) Start(lis net.Listener) {
+	s.healthReporter.Start()
+	go func() {
+		err := s.Serve(lis)
+		if err != nil {
+			log.Println("grpcServer.Serve has error: ", err.Error())
+		}
+	}()
+}
+
+// Stop reports NOT_SERVING, then waits for in-flight requests
+func (s *Server) Stop() {
+	s.healthReporter.Shutdown()
+	s.GracefulStop()
+	s.healthReporter.Close()
+	s.cleanupAdmin()
+}
+
+func StartGrpcServer(lifecycle fx.Lifecycle, service *GrpcService) error {
+	port := viper.GetInt("grpc.port")
+	grpcServer, err := NewServer(service)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
 
 	lifecycle.Append(fx.Hook{OnStart: func(ctx context.Context) error {
-		go func() {
-			log.Println("gRPC server starting on port: ", port)
-			err := grpcServer.Serve(lis)
-			if err != nil {
-				log.Println("grpcServer.Serve has error: ", err.Error())
-			}
-		}()
+		log.Println("gRPC server starting on port: ", port)
+		grpcServer.Start(lis)
 		return nil
 	}, OnStop: func(c context.Context) error {
 		log.Println("gRPC server Shutting down...")
-		grpcServer.GracefulStop()
+		grpcServer.Stop()
 		log.Println("gRPC server Shutted down")
 		return nil
 	}})
 	return nil
 }
+
+func getMethodTimeouts(key string) map[string]time.Duration {
+	methodTimeouts := map[string]time.Duration{}
+	for method, timeout := range viper.GetStringMap(key) {
+		methodTimeouts[strings.ToLower(method)] = time.Duration(cast.ToInt64(timeout)) * time.Millisecond
+	}
+	return methodTimeouts
+}
+
+// newAdminAuthenStreamServerInterceptor authenticates the streams of the admin services only,
+// the streaming methods of the app are not authenticated
+func newAdminAuthenStreamServerInterceptor(clients map[string]string, methodClients map[string][]string, adminMethods map[string]bool) grpc.StreamServerInterceptor {
+	authen := grpc_util.NewAuthenStreamServerInterceptor(clients, methodClients)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !adminMethods[strings.ToLower(info.FullMethod)] {
+			return handler(srv, ss)
+		}
+		return authen(srv, ss, info, handler)
+	}
+}
+
+// registerAdminServices registers server reflection (grpc.reflection) and channelz with the other
+// gRPC admin services (grpc.channelz). Their methods are only allowed for clients in grpc.admin-clients
+func registerAdminServices(grpcServer *grpc.Server, methodClients map[string][]string, adminMethods map[string]bool) (func(), error) {
+	registered := grpcServer.GetServiceInfo()
+	cleanup := func() {}
+	if viper.GetBool("grpc.reflection") {
+		reflection.Register(grpcServer)
+	}
+	if viper.GetBool("grpc.channelz") {
+		cleanupAdmin, err := admin.Register(grpcServer)
+		if err != nil {
+			return nil, err
+		}
+		cleanup = cleanupAdmin
+	}
+
+	adminClients := viper.GetStringSlice("grpc.admin-clients")
+	for name, info := range grpcServer.GetServiceInfo() {
+		if _, exist := registered[name]; exist {
+			continue
+		}
+		log.Println("gRPC admin service registered: ", name)
+		for _, method := range info.Methods {
+			fullMethod := strings.ToLower("/" + name + "/" + method.Name)
+			methodClients[fullMethod] = adminClients
+			adminMethods[fullMethod] = true
+		}
+	}
+	return cleanup, nil
+}
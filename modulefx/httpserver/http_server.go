@@ -8,13 +8,24 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nmtri1912/go-common/pkg/metrics"
 	"github.com/nmtri1912/go-common/utils/httputils"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
-func RunServer(lifecycle fx.Lifecycle, r *gin.Engine) {
-	mux := httputils.NewMuxServer(r)
+type ServerParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Engine    *gin.Engine
+	// Gatherer of /metrics, provided by monitor.Module. Default is prometheus.DefaultGatherer
+	Gatherer prometheus.Gatherer `optional:"true"`
+}
+
+func RunServer(params ServerParams) {
+	lifecycle := params.Lifecycle
+	mux := httputils.NewMuxServerWithGatherer(params.Engine, metrics.Gatherer(params.Gatherer))
 
 	port := viper.GetInt("server.port")
 
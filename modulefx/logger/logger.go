@@ -2,16 +2,46 @@ package logger
 
 import (
 	"context"
+	"log"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/nmtri1912/go-common/modulefx/config"
+	"github.com/nmtri1912/go-common/pkg/errorreport"
 	"github.com/nmtri1912/go-common/pkg/logger"
+	// registers the kafka type of logger.sinks
+	_ "github.com/nmtri1912/go-common/pkg/logger/kafkasink"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
-func InitLogger(lifecycle fx.Lifecycle) {
+func InitLogger(lifecycle fx.Lifecycle) error {
 	logger.InitLogger(!viper.GetBool("debug.logger"))
+	if err := logger.LoadLevels(); err != nil {
+		return err
+	}
+	if viper.GetBool("logger.watch-config") {
+		// logger.level and logger.levels are reloaded when the config file changes
+		config.OnConfigChange(func(event fsnotify.Event) {
+			if err := logger.LoadLevels(); err != nil {
+				log.Println("Reload logger levels has error: ", err.Error())
+			}
+		})
+	}
+	dispatcher, err := errorreport.NewDispatcherFromConfig()
+	if err != nil {
+		return err
+	}
+	if dispatcher != nil {
+		removeHook := logger.AddErrorHook(dispatcher.Hook)
+		lifecycle.Append(fx.Hook{OnStop: func(ctx context.Context) error {
+			removeHook()
+			return dispatcher.Close(ctx)
+		}})
+	}
 	lifecycle.Append(fx.Hook{OnStop: func(ctx context.Context) error {
 		_ = logger.Sync()
+		logger.Close()
 		return nil
 	}})
+	return nil
 }
@@ -1,8 +1,8 @@
 package monitor
 
-import (
-	"github.com/nmtri1912/go-common/pkg/monitor"
-	"go.uber.org/fx"
-)
+import "go.uber.org/fx"
 
-var Module = fx.Invoke(monitor.InitMonitorMetrics)
+var Module = fx.Options(
+	fx.Provide(NewRegistry, NewMonitorRecorder),
+	fx.Invoke(SetMaxProcs, InitMonitorMetrics, StartPush),
+)
@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"context"
+	"log"
+
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/nmtri1912/go-common/pkg/monitor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+)
+
+// NewRegistry provides the registry of the app metrics, served on /metrics. It is the prometheus default
+// registry unless monitor.default-registry is false, then metrics registered with prometheus.MustRegister,
+// promauto or prometheusutils.NewPrometheus are not served
+func NewRegistry() (prometheus.Registerer, prometheus.Gatherer) {
+	viper.SetDefault("monitor.default-registry", true)
+	if viper.GetBool("monitor.default-registry") {
+		return prometheus.DefaultRegisterer, prometheus.DefaultGatherer
+	}
+	log.Println("Using an isolated metric registry")
+	registry := metrics.NewRegistry()
+	return registry, registry
+}
+
+// NewMonitorRecorder provides the recorder to inject, e.g. to declare metrics with monitor.NewCounter
+func NewMonitorRecorder(registerer prometheus.Registerer) *monitor.MonitorRecorder {
+	return monitor.NewMonitorRecorder(registerer)
+}
+
+// InitMonitorMetrics makes the provided recorder the default one, used by monitor.Timer
+func InitMonitorMetrics(recorder *monitor.MonitorRecorder) {
+	monitor.SetDefault(recorder)
+}
+
+// SetMaxProcs sets GOMAXPROCS from the cgroup CPU quota, unless monitor.auto-maxprocs is false
+func SetMaxProcs() {
+	viper.SetDefault("monitor.auto-maxprocs", true)
+	if !viper.GetBool("monitor.auto-maxprocs") {
+		return
+	}
+	if procs, changed := monitor.SetMaxProcsFromCgroup(); changed {
+		log.Println("GOMAXPROCS set from the cgroup CPU quota:", procs)
+	}
+}
+
+// StartPush pushes the metrics as configured by monitor.push.*, the final push is done when the app stops
+func StartPush(lifecycle fx.Lifecycle, gatherer prometheus.Gatherer) error {
+	pusher, interval, err := monitor.NewPusherFromConfig(gatherer)
+	if err != nil || pusher == nil {
+		return err
+	}
+	var periodic *monitor.PeriodicPusher
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			log.Println("Pushing metrics to", viper.GetString("monitor.push.url"))
+			periodic = monitor.StartPush(pusher, interval)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := periodic.Stop(ctx); err != nil {
+				log.Println("Final push of metrics has error: ", err.Error())
+			}
+			return nil
+		},
+	})
+	return nil
+}
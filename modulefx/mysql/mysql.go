@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/dlmiddlecote/sqlstats"
+	"github.com/nmtri1912/go-common/pkg/health"
+	"github.com/nmtri1912/go-common/pkg/metrics"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
@@ -16,7 +18,15 @@ import (
 	"gorm.io/gorm/schema"
 )
 
-func NewDB(lifecycle fx.Lifecycle) *gorm.DB {
+type DBParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	// Registerer of the connection pool stats, provided by monitor.Module. Default is prometheus.DefaultRegisterer
+	Registerer prometheus.Registerer `optional:"true"`
+}
+
+func NewDB(params DBParams) *gorm.DB {
+	lifecycle := params.Lifecycle
 	username := viper.GetString("mysql.username")
 	password := viper.GetString("mysql.password")
 	url := viper.GetString("mysql.url")
@@ -56,7 +66,9 @@ func NewDB(lifecycle fx.Lifecycle) *gorm.DB {
 
 	// Register stats with Prometheus
 	collector := sqlstats.NewStatsCollector(mysqlSchema, sqlDb)
-	prometheus.MustRegister(collector)
+	metrics.Register(params.Registerer, collector)
+
+	health.Register("mysql", health.PingCheck(sqlDb))
 
 	log.Println("Connect to database successfully")
 	return db
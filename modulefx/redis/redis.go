@@ -7,13 +7,24 @@ import (
 
 	"github.com/go-redis/redis/extra/redisotel/v8"
 	redisLib "github.com/go-redis/redis/v8"
+	"github.com/nmtri1912/go-common/pkg/health"
+	"github.com/nmtri1912/go-common/pkg/metrics"
 	"github.com/nmtri1912/go-common/pkg/redis"
 	"github.com/nmtri1912/go-common/pkg/redisprom"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
-func NewCache(lifecycle fx.Lifecycle) redis.Cache {
+type CacheParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	// Registerer of the monitor hook, provided by monitor.Module. Default is prometheus.DefaultRegisterer
+	Registerer prometheus.Registerer `optional:"true"`
+}
+
+func NewCache(params CacheParams) redis.Cache {
+	lifecycle := params.Lifecycle
 	addresses := viper.GetString("redis.addresses")
 
 	if len(addresses) == 0 {
@@ -27,7 +38,7 @@ func NewCache(lifecycle fx.Lifecycle) redis.Cache {
 	//redis.monitor-hook = true => using monitor hook
 	monitorHook := viper.GetBool("redis.monitor-hook")
 	if monitorHook {
-		hook := redisprom.NewHook()
+		hook := redisprom.NewHook(redisprom.WithRegisterer(metrics.Registerer(params.Registerer)))
 		client.AddHook(hook)
 	}
 	client.AddHook(redisotel.NewTracingHook())
@@ -38,6 +49,7 @@ func NewCache(lifecycle fx.Lifecycle) redis.Cache {
 	}
 
 	log.Println("Connect redis successfully")
+	health.Register("redis", health.RedisCheck(client))
 
 	lifecycle.Append(fx.Hook{OnStop: func(ctx context.Context) error {
 		log.Println("Closing redis connection")
@@ -7,13 +7,23 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/nmtri1912/go-common/pkg/metrics"
 	"github.com/nmtri1912/go-common/utils/httputils"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
-func RunServer(lifecycle fx.Lifecycle) {
-	mux := httputils.NewMuxServer(nil)
+type ServerParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	// Gatherer of /metrics, provided by monitor.Module. Default is prometheus.DefaultGatherer
+	Gatherer prometheus.Gatherer `optional:"true"`
+}
+
+func RunServer(params ServerParams) {
+	lifecycle := params.Lifecycle
+	mux := httputils.NewMuxServerWithGatherer(nil, metrics.Gatherer(params.Gatherer))
 
 	port := viper.GetInt("server.port")
 
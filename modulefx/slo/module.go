@@ -0,0 +1,5 @@
+package slo
+
+import "go.uber.org/fx"
+
+var Module = fx.Invoke(InitSLO)
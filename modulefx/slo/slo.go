@@ -0,0 +1,24 @@
+package slo
+
+import (
+	"log"
+
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/nmtri1912/go-common/pkg/monitor"
+	"github.com/nmtri1912/go-common/pkg/slo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// InitSLO tracks the objectives of slo.objectives on the invocations of the recorder
+// and registers their burn-rate and error budget gauges, it requires the monitor module
+func InitSLO(recorder *monitor.MonitorRecorder, registerer prometheus.Registerer) error {
+	config, err := slo.LoadConfig()
+	if err != nil || len(config.Objectives) == 0 {
+		return err
+	}
+	log.Println("Tracking", len(config.Objectives), "SLOs")
+	tracker := slo.NewTracker(config, recorder.ConstLabels())
+	recorder.AddObserver(tracker.Observe)
+	metrics.Register(registerer, tracker)
+	return nil
+}
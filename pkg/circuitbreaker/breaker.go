@@ -0,0 +1,167 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type Config struct {
+	// Window is the period failures are counted over while closed
+	Window time.Duration
+	// MinRequests is the number of requests in a window before the breaker can open
+	MinRequests int
+	// FailureRatio opens the breaker when failures/requests of a window reaches it
+	FailureRatio float64
+	// OpenTimeout is the time the breaker stays open before probing in half-open state
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests is the number of probes in half-open state, all must succeed to close the breaker
+	HalfOpenMaxRequests int
+}
+
+type StateChangeFunc func(name string, from, to State)
+
+// Breaker is a circuit breaker counting failures over a fixed window.
+// When open, requests are rejected until OpenTimeout, then a few probes decide to close or reopen it
+type Breaker struct {
+	name          string
+	config        Config
+	onStateChange StateChangeFunc
+
+	mu                sync.Mutex
+	state             State
+	generation        uint64
+	windowStart       time.Time
+	requests          int
+	failures          int
+	openedAt          time.Time
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+}
+
+func New(name string, config Config, onStateChange StateChangeFunc) *Breaker {
+	if config.HalfOpenMaxRequests < 1 {
+		config.HalfOpenMaxRequests = 1
+	}
+	return &Breaker{
+		name:          name,
+		config:        config,
+		onStateChange: onStateChange,
+		windowStart:   time.Now(),
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.checkOpenTimeout(time.Now())
+	return b.state
+}
+
+// Allow returns ErrOpen when the request must be rejected. Otherwise, the caller must report
+// the result of the request with done
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.checkOpenTimeout(now)
+	switch b.state {
+	case StateOpen:
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.halfOpenInFlight >= b.config.HalfOpenMaxRequests {
+			return nil, ErrOpen
+		}
+		b.halfOpenInFlight++
+	case StateClosed:
+		if now.Sub(b.windowStart) > b.config.Window {
+			b.resetWindow(now)
+		}
+	}
+
+	generation := b.generation
+	return func(success bool) {
+		b.done(generation, success)
+	}, nil
+}
+
+func (b *Breaker) done(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// result of a request started before the last state change
+	if generation != b.generation {
+		return
+	}
+
+	now := time.Now()
+	switch b.state {
+	case StateHalfOpen:
+		b.halfOpenInFlight--
+		if !success {
+			b.setState(StateOpen, now)
+			return
+		}
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= b.config.HalfOpenMaxRequests {
+			b.setState(StateClosed, now)
+		}
+	case StateClosed:
+		b.requests++
+		if !success {
+			b.failures++
+		}
+		if b.requests >= b.config.MinRequests && float64(b.failures)/float64(b.requests) >= b.config.FailureRatio {
+			b.setState(StateOpen, now)
+		}
+	}
+}
+
+func (b *Breaker) checkOpenTimeout(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.config.OpenTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *Breaker) setState(state State, now time.Time) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.resetWindow(now)
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccesses = 0
+	if state == StateOpen {
+		b.openedAt = now
+	}
+	if b.onStateChange != nil {
+		b.onStateChange(b.name, from, state)
+	}
+}
+
+func (b *Breaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
@@ -0,0 +1,159 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestBreaker(transitions *[]State) *Breaker {
+	return New("test", Config{
+		Window:              time.Minute,
+		MinRequests:         4,
+		FailureRatio:        0.5,
+		OpenTimeout:         20 * time.Millisecond,
+		HalfOpenMaxRequests: 2,
+	}, func(name string, from, to State) {
+		*transitions = append(*transitions, to)
+	})
+}
+
+func report(t *testing.T, b *Breaker, success bool) {
+	t.Helper()
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() = %v, want allowed in state %v", err, b.State())
+	}
+	done(success)
+}
+
+func TestBreakerOpensOnFailureRatio(t *testing.T) {
+	var transitions []State
+	b := newTestBreaker(&transitions)
+
+	report(t, b, false)
+	report(t, b, false)
+	report(t, b, true)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %v before MinRequests, want closed", b.State())
+	}
+	report(t, b, false)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %v with 3/4 failures, want open", b.State())
+	}
+	if _, err := b.Allow(); err != ErrOpen {
+		t.Errorf("Allow() = %v while open, want ErrOpen", err)
+	}
+	if len(transitions) != 1 || transitions[0] != StateOpen {
+		t.Errorf("transitions = %v, want [open]", transitions)
+	}
+}
+
+func TestBreakerStaysClosedBelowRatio(t *testing.T) {
+	var transitions []State
+	b := newTestBreaker(&transitions)
+	for i := 0; i < 10; i++ {
+		report(t, b, i%4 != 3)
+	}
+	if b.State() != StateClosed {
+		t.Errorf("state = %v with 2/10 failures, want closed", b.State())
+	}
+}
+
+func TestBreakerHalfOpenCloses(t *testing.T) {
+	var transitions []State
+	b := newTestBreaker(&transitions)
+	for i := 0; i < 4; i++ {
+		report(t, b, false)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %v after OpenTimeout, want half-open", b.State())
+	}
+
+	first, err := b.Allow()
+	if err != nil {
+		t.Fatalf("first probe: %v", err)
+	}
+	second, err := b.Allow()
+	if err != nil {
+		t.Fatalf("second probe: %v", err)
+	}
+	if _, err := b.Allow(); err != ErrOpen {
+		t.Errorf("third probe = %v, want ErrOpen above HalfOpenMaxRequests", err)
+	}
+	first(true)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %v after one successful probe, want half-open", b.State())
+	}
+	second(true)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %v after all probes succeeded, want closed", b.State())
+	}
+	want := []State{StateOpen, StateHalfOpen, StateClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+func TestBreakerHalfOpenReopens(t *testing.T) {
+	var transitions []State
+	b := newTestBreaker(&transitions)
+	for i := 0; i < 4; i++ {
+		report(t, b, false)
+	}
+	time.Sleep(30 * time.Millisecond)
+	report(t, b, false)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %v after a failed probe, want open", b.State())
+	}
+	if _, err := b.Allow(); err != ErrOpen {
+		t.Errorf("Allow() = %v after reopening, want ErrOpen", err)
+	}
+}
+
+func TestBreakerIgnoresStaleResults(t *testing.T) {
+	var transitions []State
+	b := newTestBreaker(&transitions)
+	stale, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		report(t, b, false)
+	}
+	time.Sleep(30 * time.Millisecond)
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// started while closed, must not count as a half-open probe
+	stale(true)
+	stale(true)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %v after stale results, want half-open", b.State())
+	}
+	probe(true)
+	if b.State() != StateHalfOpen {
+		t.Errorf("state = %v after 1 of 2 probes, want half-open", b.State())
+	}
+}
+
+func TestBreakerWindowReset(t *testing.T) {
+	var transitions []State
+	b := New("test", Config{Window: 20 * time.Millisecond, MinRequests: 4, FailureRatio: 0.5, OpenTimeout: time.Minute}, func(name string, from, to State) {
+		transitions = append(transitions, to)
+	})
+	report(t, b, false)
+	report(t, b, false)
+	report(t, b, false)
+	time.Sleep(30 * time.Millisecond)
+	report(t, b, false)
+	if b.State() != StateClosed {
+		t.Errorf("state = %v, failures of the previous window must not count", b.State())
+	}
+}
@@ -0,0 +1,65 @@
+package discovery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+type weightKey struct{}
+
+// SetWeight stores the weight of addr, used by the weighted balancer
+func SetWeight(addr resolver.Address, weight uint32) resolver.Address {
+	if addr.BalancerAttributes == nil {
+		addr.BalancerAttributes = attributes.New(weightKey{}, weight)
+	} else {
+		addr.BalancerAttributes = addr.BalancerAttributes.WithValue(weightKey{}, weight)
+	}
+	return addr
+}
+
+// GetWeight returns the weight of addr, 1 if not set
+func GetWeight(addr resolver.Address) uint32 {
+	if addr.BalancerAttributes == nil {
+		return 1
+	}
+	weight, ok := addr.BalancerAttributes.Value(weightKey{}).(uint32)
+	if !ok || weight == 0 {
+		return 1
+	}
+	return weight
+}
+
+// ParseAddresses parses entries with format `host:port` or `host:port=weight`.
+// Empty entries and entries starting with # are ignored
+func ParseAddresses(entries []string) ([]resolver.Address, error) {
+	addresses := make([]resolver.Address, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		addr, weight := entry, uint64(1)
+		if i := strings.LastIndex(entry, "="); i >= 0 {
+			var err error
+			addr = entry[:i]
+			weight, err = strconv.ParseUint(entry[i+1:], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid weight of %s: %w", entry, err)
+			}
+		}
+		addresses = append(addresses, SetWeight(resolver.Address{Addr: addr}, uint32(weight)))
+	}
+	return addresses, nil
+}
+
+// endpoint returns the part after the scheme of a target, e.g. `a:1,b:2` of `static:///a:1,b:2`
+func endpoint(target resolver.Target) string {
+	if len(target.URL.Opaque) > 0 {
+		return target.URL.Opaque
+	}
+	return strings.TrimPrefix(target.URL.Path, "/")
+}
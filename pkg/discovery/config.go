@@ -0,0 +1,28 @@
+package discovery
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultRegistryTTL     = 30 * time.Second
+	defaultRefreshInterval = 30 * time.Second
+)
+
+// RegistryTTL returns grpc.registry.ttl-sec, the time an instance stays registered without heartbeat
+func RegistryTTL() time.Duration {
+	if ttl := viper.GetInt("grpc.registry.ttl-sec"); ttl > 0 {
+		return time.Duration(ttl) * time.Second
+	}
+	return defaultRegistryTTL
+}
+
+// RefreshInterval returns <service>.discovery.refresh-sec, the polling interval of dns and redis resolvers
+func RefreshInterval(service string) time.Duration {
+	if interval := viper.GetInt(service + ".discovery.refresh-sec"); interval > 0 {
+		return time.Duration(interval) * time.Second
+	}
+	return defaultRefreshInterval
+}
@@ -0,0 +1,44 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+const DNSScheme = "dnspoll"
+
+type dnsBuilder struct {
+	interval time.Duration
+}
+
+// NewDNSBuilder resolves `dnspoll:///host:port` every interval. Unlike the built-in dns resolver,
+// which only re-resolves on connection failures, new instances behind the name are picked up
+func NewDNSBuilder(interval time.Duration) resolver.Builder {
+	return &dnsBuilder{interval: interval}
+}
+
+func (b *dnsBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	host, port, err := net.SplitHostPort(endpoint(target))
+	if err != nil {
+		return nil, err
+	}
+	lookup := func(ctx context.Context) ([]resolver.Address, error) {
+		ips, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		addresses := make([]resolver.Address, 0, len(ips))
+		for _, ip := range ips {
+			addresses = append(addresses, resolver.Address{Addr: net.JoinHostPort(ip, port), ServerName: host})
+		}
+		return addresses, nil
+	}
+	return newPollingResolver(cc, lookup, b.interval), nil
+}
+
+func (b *dnsBuilder) Scheme() string {
+	return DNSScheme
+}
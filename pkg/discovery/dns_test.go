@@ -0,0 +1,37 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+func TestDNSResolver(t *testing.T) {
+	cc := newFakeClientConn()
+	r, err := NewDNSBuilder(time.Hour).Build(parseTarget(t, "dnspoll:///localhost:50051"), cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	state := <-cc.states
+	if len(state.Addresses) == 0 {
+		t.Fatal("localhost is not resolved")
+	}
+	for _, address := range state.Addresses {
+		if address.Addr != "127.0.0.1:50051" && address.Addr != "[::1]:50051" {
+			t.Errorf("address = %s", address.Addr)
+		}
+		if address.ServerName != "localhost" {
+			t.Errorf("server name = %s, want localhost", address.ServerName)
+		}
+	}
+}
+
+func TestDNSResolverMissingPort(t *testing.T) {
+	_, err := NewDNSBuilder(time.Hour).Build(parseTarget(t, "dnspoll:///localhost"), newFakeClientConn(), resolver.BuildOptions{})
+	if err == nil {
+		t.Error("Build() without port should fail")
+	}
+}
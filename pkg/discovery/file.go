@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/resolver"
+)
+
+const FileScheme = "file"
+
+type fileBuilder struct{}
+
+// NewFileBuilder resolves `file:///path/to/endpoints` to the addresses listed in the file, one `host:port`
+// or `host:port=weight` per line. The file is watched and changes are applied without restart
+func NewFileBuilder() resolver.Builder {
+	return &fileBuilder{}
+}
+
+func (b *fileBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	path := "/" + endpoint(target)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// watch the directory, files replaced by rename (e.g. Kubernetes ConfigMap) are not tracked otherwise
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	r := &fileResolver{
+		cc:      cc,
+		path:    path,
+		watcher: watcher,
+	}
+	if err := r.load(); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	r.wg.Add(1)
+	go r.watch()
+	return r, nil
+}
+
+func (b *fileBuilder) Scheme() string {
+	return FileScheme
+}
+
+type fileResolver struct {
+	cc      resolver.ClientConn
+	path    string
+	watcher *fsnotify.Watcher
+	wg      sync.WaitGroup
+}
+
+func (r *fileResolver) load() error {
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	addresses, err := ParseAddresses(strings.Split(string(content), "\n"))
+	if err != nil {
+		return err
+	}
+	return r.cc.UpdateState(resolver.State{Addresses: addresses})
+}
+
+func (r *fileResolver) watch() {
+	defer r.wg.Done()
+	for {
+		select {
+		case _, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if err := r.load(); err != nil {
+				logger.L().Warn("Can not load endpoints file", zap.String("path", r.path), zap.Error(err))
+				r.cc.ReportError(err)
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.L().Warn("Endpoints file watcher error", zap.String("path", r.path), zap.Error(err))
+		}
+	}
+}
+
+func (r *fileResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *fileResolver) Close() {
+	_ = r.watcher.Close()
+	r.wg.Wait()
+}
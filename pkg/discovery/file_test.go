@@ -0,0 +1,47 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/resolver"
+)
+
+func TestFileResolver(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "endpoints")
+	if err := os.WriteFile(path, []byte("# instances\na:1\nb:2=3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cc := newFakeClientConn()
+	r, err := NewFileBuilder().Build(parseTarget(t, "file://"+filepath.ToSlash(path)), cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got := cc.nextAddresses(t); !reflect.DeepEqual(got, []string{"a:1", "b:2"}) {
+		t.Errorf("addresses = %v", got)
+	}
+
+	// replaced by rename, as a Kubernetes ConfigMap
+	replacement := filepath.Join(dir, "endpoints.new")
+	if err := os.WriteFile(replacement, []byte("c:3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(replacement, path); err != nil {
+		t.Fatal(err)
+	}
+	// the watcher may see several events, wait for the new content
+	for !reflect.DeepEqual(cc.nextAddresses(t), []string{"c:3"}) {
+	}
+}
+
+func TestFileResolverMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+	_, err := NewFileBuilder().Build(parseTarget(t, "file://"+filepath.ToSlash(path)), newFakeClientConn(), resolver.BuildOptions{})
+	if err == nil {
+		t.Error("Build() of a missing file should fail")
+	}
+}
@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+type lookupFunc func(ctx context.Context) ([]resolver.Address, error)
+
+// pollingResolver calls lookup every interval, or when gRPC asks for a re-resolution
+type pollingResolver struct {
+	cc         resolver.ClientConn
+	lookup     lookupFunc
+	interval   time.Duration
+	resolveNow chan struct{}
+	quit       chan struct{}
+	wg         sync.WaitGroup
+}
+
+func newPollingResolver(cc resolver.ClientConn, lookup lookupFunc, interval time.Duration) *pollingResolver {
+	r := &pollingResolver{
+		cc:         cc,
+		lookup:     lookup,
+		interval:   interval,
+		resolveNow: make(chan struct{}, 1),
+		quit:       make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.watch()
+	return r
+}
+
+func (r *pollingResolver) watch() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.resolve()
+		select {
+		case <-ticker.C:
+		case <-r.resolveNow:
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+func (r *pollingResolver) resolve() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+	defer cancel()
+	addresses, err := r.lookup(ctx)
+	if err != nil {
+		r.cc.ReportError(err)
+		return
+	}
+	_ = r.cc.UpdateState(resolver.State{Addresses: addresses})
+}
+
+func (r *pollingResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *pollingResolver) Close() {
+	close(r.quit)
+	r.wg.Wait()
+}
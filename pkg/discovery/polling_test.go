@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeClientConn records the states and errors reported by a resolver
+type fakeClientConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+	errors chan error
+}
+
+func newFakeClientConn() *fakeClientConn {
+	return &fakeClientConn{
+		states: make(chan resolver.State, 10),
+		errors: make(chan error, 10),
+	}
+}
+
+func (cc *fakeClientConn) UpdateState(state resolver.State) error {
+	cc.states <- state
+	return nil
+}
+
+func (cc *fakeClientConn) ReportError(err error) {
+	cc.errors <- err
+}
+
+// nextAddresses waits for the next state and returns its addresses
+func (cc *fakeClientConn) nextAddresses(t *testing.T) []string {
+	t.Helper()
+	select {
+	case state := <-cc.states:
+		addresses := make([]string, 0, len(state.Addresses))
+		for _, address := range state.Addresses {
+			addresses = append(addresses, address.Addr)
+		}
+		return addresses
+	case <-time.After(5 * time.Second):
+		t.Fatal("no state update")
+		return nil
+	}
+}
+
+func (cc *fakeClientConn) nextError(t *testing.T) error {
+	t.Helper()
+	select {
+	case err := <-cc.errors:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("no error reported")
+		return nil
+	}
+}
+
+func parseTarget(t *testing.T, target string) resolver.Target {
+	t.Helper()
+	parsed, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolver.Target{URL: *parsed}
+}
+
+func TestPollingResolver(t *testing.T) {
+	cc := newFakeClientConn()
+	lookups := make(chan error, 10)
+	r := newPollingResolver(cc, func(ctx context.Context) ([]resolver.Address, error) {
+		if err := <-lookups; err != nil {
+			return nil, err
+		}
+		return []resolver.Address{{Addr: "a:1"}}, nil
+	}, time.Hour)
+	defer r.Close()
+
+	lookups <- nil
+	if got := cc.nextAddresses(t); len(got) != 1 || got[0] != "a:1" {
+		t.Errorf("addresses = %v", got)
+	}
+	// re-resolved on demand, before the interval
+	lookups <- errors.New("unreachable")
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	if err := cc.nextError(t); err.Error() != "unreachable" {
+		t.Errorf("reported %v", err)
+	}
+	lookups <- nil
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	cc.nextAddresses(t)
+}
+
+func TestPollingResolverInterval(t *testing.T) {
+	cc := newFakeClientConn()
+	r := newPollingResolver(cc, func(ctx context.Context) ([]resolver.Address, error) {
+		return []resolver.Address{{Addr: "a:1"}}, nil
+	}, 10*time.Millisecond)
+	defer r.Close()
+	for i := 0; i < 3; i++ {
+		cc.nextAddresses(t)
+	}
+}
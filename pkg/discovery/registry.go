@@ -0,0 +1,119 @@
+package discovery
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	RedisScheme       = "redis"
+	registryKeyPrefix = "discovery:"
+)
+
+// Registry stores the instances of a service in a Redis sorted set, scored by their expiry time.
+// An instance must renew its registration before ttl, otherwise it's no longer resolved
+type Registry struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+func NewRegistry(client redis.UniversalClient, ttl time.Duration) *Registry {
+	return &Registry{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func (r *Registry) Register(ctx context.Context, service, address string) error {
+	now := time.Now()
+	key := registryKeyPrefix + service
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, &redis.Z{Score: float64(now.Add(r.ttl).UnixMilli()), Member: address})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+		return nil
+	})
+	return err
+}
+
+func (r *Registry) Deregister(ctx context.Context, service, address string) error {
+	return r.client.ZRem(ctx, registryKeyPrefix+service, address).Err()
+}
+
+// Lookup returns the addresses of alive instances of service
+func (r *Registry) Lookup(ctx context.Context, service string) ([]string, error) {
+	return r.client.ZRangeByScore(ctx, registryKeyPrefix+service, &redis.ZRangeBy{
+		Min: strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+}
+
+// Heartbeat registers the instance every ttl/3 until the returned function is called, which deregisters it
+func (r *Registry) Heartbeat(service, address string) func() {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	register := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), r.ttl/3)
+		defer cancel()
+		if err := r.Register(ctx, service, address); err != nil {
+			logger.L().Warn("Can not register service", zap.String("service", service), zap.String("address", address), zap.Error(err))
+		}
+	}
+	register()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(r.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				register()
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-done
+		ctx, cancel := context.WithTimeout(context.Background(), r.ttl/3)
+		defer cancel()
+		if err := r.Deregister(ctx, service, address); err != nil {
+			logger.L().Warn("Can not deregister service", zap.String("service", service), zap.String("address", address), zap.Error(err))
+		}
+	}
+}
+
+type redisBuilder struct {
+	registry *Registry
+	interval time.Duration
+}
+
+// NewRedisBuilder resolves `redis:///<service>` to the instances registered in registry, polled every interval
+func NewRedisBuilder(registry *Registry, interval time.Duration) resolver.Builder {
+	return &redisBuilder{
+		registry: registry,
+		interval: interval,
+	}
+}
+
+func (b *redisBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	service := endpoint(target)
+	lookup := func(ctx context.Context) ([]resolver.Address, error) {
+		instances, err := b.registry.Lookup(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		return ParseAddresses(instances)
+	}
+	return newPollingResolver(cc, lookup, b.interval), nil
+}
+
+func (b *redisBuilder) Scheme() string {
+	return RedisScheme
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/resolver"
+)
+
+func newTestRegistry(t *testing.T, ttl time.Duration) *Registry {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRegistry(client, ttl)
+}
+
+func TestRegistryTTL(t *testing.T) {
+	registry := newTestRegistry(t, 100*time.Millisecond)
+	ctx := context.Background()
+
+	if err := registry.Register(ctx, "users", "a:1"); err != nil {
+		t.Fatal(err)
+	}
+	if instances, _ := registry.Lookup(ctx, "users"); !reflect.DeepEqual(instances, []string{"a:1"}) {
+		t.Errorf("instances = %v", instances)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if instances, _ := registry.Lookup(ctx, "users"); len(instances) > 0 {
+		t.Errorf("instances = %v, the expired instance should not be resolved", instances)
+	}
+}
+
+func TestRegistryHeartbeat(t *testing.T) {
+	registry := newTestRegistry(t, 90*time.Millisecond)
+	ctx := context.Background()
+
+	stop := registry.Heartbeat("users", "a:1")
+	time.Sleep(250 * time.Millisecond)
+	if instances, _ := registry.Lookup(ctx, "users"); !reflect.DeepEqual(instances, []string{"a:1"}) {
+		t.Errorf("instances = %v, the instance should be renewed", instances)
+	}
+	stop()
+	if instances, _ := registry.Lookup(ctx, "users"); len(instances) > 0 {
+		t.Errorf("instances = %v, the instance should be deregistered", instances)
+	}
+}
+
+func TestRedisResolver(t *testing.T) {
+	registry := newTestRegistry(t, time.Minute)
+	ctx := context.Background()
+	if err := registry.Register(ctx, "users", "a:1"); err != nil {
+		t.Fatal(err)
+	}
+
+	cc := newFakeClientConn()
+	r, err := NewRedisBuilder(registry, time.Hour).Build(parseTarget(t, "redis:///users"), cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got := cc.nextAddresses(t); !reflect.DeepEqual(got, []string{"a:1"}) {
+		t.Errorf("addresses = %v", got)
+	}
+
+	if err := registry.Register(ctx, "users", "b:2"); err != nil {
+		t.Fatal(err)
+	}
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	if got := cc.nextAddresses(t); !reflect.DeepEqual(got, []string{"a:1", "b:2"}) {
+		t.Errorf("addresses = %v", got)
+	}
+}
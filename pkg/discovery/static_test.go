@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/resolver"
+)
+
+func TestStaticResolver(t *testing.T) {
+	cc := newFakeClientConn()
+	r, err := NewStaticBuilder().Build(parseTarget(t, "static:///a:1,b:2=3"), cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	state := <-cc.states
+	if len(state.Addresses) != 2 {
+		t.Fatalf("addresses = %v", state.Addresses)
+	}
+	for i, want := range []struct {
+		addr   string
+		weight uint32
+	}{{"a:1", 1}, {"b:2", 3}} {
+		if address := state.Addresses[i]; address.Addr != want.addr || GetWeight(address) != want.weight {
+			t.Errorf("address %d = %s with weight %d, want %s with weight %d", i, address.Addr, GetWeight(address), want.addr, want.weight)
+		}
+	}
+}
+
+func TestStaticResolverInvalidWeight(t *testing.T) {
+	_, err := NewStaticBuilder().Build(parseTarget(t, "static:///a:1=heavy"), newFakeClientConn(), resolver.BuildOptions{})
+	if err == nil {
+		t.Error("Build() with an invalid weight should fail")
+	}
+}
@@ -0,0 +1,54 @@
+package discovery
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// WeightedBalancerName picks a ready backend randomly, proportionally to its weight (see SetWeight)
+const WeightedBalancerName = "weighted"
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(WeightedBalancerName, &weightedPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type weightedPickerBuilder struct{}
+
+func (b *weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	picker := &weightedPicker{
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())), // #nosec G404 not used for security
+	}
+	var total uint64
+	for subConn, subConnInfo := range info.ReadySCs {
+		total += uint64(GetWeight(subConnInfo.Address))
+		picker.subConns = append(picker.subConns, subConn)
+		picker.cumulativeWeights = append(picker.cumulativeWeights, total)
+	}
+	return picker
+}
+
+type weightedPicker struct {
+	mu                sync.Mutex
+	rand              *rand.Rand
+	subConns          []balancer.SubConn
+	cumulativeWeights []uint64
+}
+
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	total := p.cumulativeWeights[len(p.cumulativeWeights)-1]
+	p.mu.Lock()
+	n := uint64(p.rand.Int63n(int64(total)))
+	p.mu.Unlock()
+	i := sort.Search(len(p.cumulativeWeights), func(i int) bool {
+		return p.cumulativeWeights[i] > n
+	})
+	return balancer.PickResult{SubConn: p.subConns[i]}, nil
+}
@@ -0,0 +1,47 @@
+package discovery
+
+import (
+	"math"
+	"testing"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeSubConn struct {
+	balancer.SubConn
+	name string
+}
+
+func TestWeightedPickerDistribution(t *testing.T) {
+	weights := map[string]uint32{"a:1": 1, "b:2": 3, "c:3": 6}
+	readySCs := make(map[balancer.SubConn]base.SubConnInfo)
+	for addr, weight := range weights {
+		readySCs[&fakeSubConn{name: addr}] = base.SubConnInfo{Address: SetWeight(resolver.Address{Addr: addr}, weight)}
+	}
+	picker := (&weightedPickerBuilder{}).Build(base.PickerBuildInfo{ReadySCs: readySCs})
+
+	const picks = 100000
+	counts := make(map[string]int)
+	for i := 0; i < picks; i++ {
+		result, err := picker.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[result.SubConn.(*fakeSubConn).name]++
+	}
+	for addr, weight := range weights {
+		want := float64(weight) / 10
+		if got := float64(counts[addr]) / picks; math.Abs(got-want) > 0.01 {
+			t.Errorf("%s picked %.3f of the time, want %.3f", addr, got, want)
+		}
+	}
+}
+
+func TestWeightedPickerNoReadySubConn(t *testing.T) {
+	picker := (&weightedPickerBuilder{}).Build(base.PickerBuildInfo{})
+	if _, err := picker.Pick(balancer.PickInfo{}); err != balancer.ErrNoSubConnAvailable {
+		t.Errorf("Pick() = %v, want %v", err, balancer.ErrNoSubConnAvailable)
+	}
+}
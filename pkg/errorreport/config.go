@@ -0,0 +1,36 @@
+package errorreport
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// NewDispatcherFromConfig creates the dispatcher configured by logger.error-report.*, nil when disabled
+func NewDispatcherFromConfig() (*Dispatcher, error) {
+	if !viper.GetBool("logger.error-report.enabled") {
+		return nil, nil
+	}
+	var reporters []Reporter
+	if path := viper.GetString("logger.error-report.file"); len(path) > 0 {
+		reporter, err := NewFileReporter(path)
+		if err != nil {
+			return nil, err
+		}
+		reporters = append(reporters, reporter)
+	}
+	if url := viper.GetString("logger.error-report.webhook.url"); len(url) > 0 {
+		reporters = append(reporters, NewWebhookReporter(url, viper.GetStringMapString("logger.error-report.webhook.headers")))
+	}
+	if dsn := viper.GetString("logger.error-report.sentry.dsn"); len(dsn) > 0 {
+		reporter, err := NewSentryReporter(dsn,
+			viper.GetString("logger.error-report.sentry.environment"),
+			viper.GetString("logger.error-report.sentry.release"))
+		if err != nil {
+			return nil, err
+		}
+		reporters = append(reporters, reporter)
+	}
+	window := time.Duration(viper.GetInt("logger.error-report.window-sec")) * time.Second
+	return NewDispatcher(window, reporters...), nil
+}
@@ -0,0 +1,224 @@
+package errorreport
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultWindow     = time.Minute
+	defaultBufferSize = 1000
+	defaultTimeout    = 5 * time.Second
+	// maxFingerprints bounds the fingerprints remembered by a dispatcher, the least recently seen are forgotten
+	maxFingerprints = 10000
+)
+
+var droppedCounter = metrics.Shared(prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "error_report_dropped_total",
+	Help: "Number of error reports not sent",
+}, []string{"reason"})).(*prometheus.CounterVec)
+
+// Event is an error log entry, grouped with its duplicates by Fingerprint
+type Event struct {
+	Fingerprint string                 `json:"fingerprint"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger,omitempty"`
+	Message     string                 `json:"message"`
+	Error       string                 `json:"error,omitempty"`
+	Caller      string                 `json:"caller,omitempty"`
+	Stack       string                 `json:"stack,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+	Time        time.Time              `json:"time"`
+	// Count is the number of occurrences since the previous report of the fingerprint, this one included
+	Count int `json:"count"`
+}
+
+// Reporter sends events to an error tracker. Reporters implementing io.Closer are closed by Dispatcher.Close
+type Reporter interface {
+	Report(ctx context.Context, event Event) error
+}
+
+// Dispatcher reports the error entries of the logger in background. An event is reported
+// on the first occurrence of its fingerprint, duplicates are counted and reported at most once per window
+type Dispatcher struct {
+	reporters []Reporter
+	window    time.Duration
+	timeout   time.Duration
+
+	mutex sync.Mutex
+	seen  map[string]*list.Element
+	// recent orders the occurrences from the most to the least recently seen
+	recent *list.List
+
+	queue chan Event
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+type occurrence struct {
+	fingerprint string
+	reportedAt  time.Time
+	count       int
+}
+
+// NewDispatcher starts a dispatcher, window <= 0 uses 1 minute
+func NewDispatcher(window time.Duration, reporters ...Reporter) *Dispatcher {
+	if window <= 0 {
+		window = defaultWindow
+	}
+	d := &Dispatcher{
+		reporters: reporters,
+		window:    window,
+		timeout:   defaultTimeout,
+		seen:      make(map[string]*list.Element),
+		recent:    list.New(),
+		queue:     make(chan Event, defaultBufferSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Hook is the logger.ErrorHook feeding the dispatcher, register it with logger.AddErrorHook
+func (d *Dispatcher) Hook(entry zapcore.Entry, fields []zapcore.Field) {
+	event := newEvent(entry, fields)
+	if !d.admit(&event) {
+		return
+	}
+	if entry.Level > zapcore.ErrorLevel {
+		// the process may exit or unwind right after DPanic, Panic and Fatal
+		d.report(event)
+		return
+	}
+	select {
+	case <-d.stop:
+		droppedCounter.WithLabelValues("closed").Inc()
+	case d.queue <- event:
+	default:
+		droppedCounter.WithLabelValues("buffer_full").Inc()
+	}
+}
+
+// admit counts the occurrence and tells whether event has to be reported now
+func (d *Dispatcher) admit(event *Event) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	element, exist := d.seen[event.Fingerprint]
+	if exist {
+		d.recent.MoveToFront(element)
+	} else {
+		element = d.recent.PushFront(&occurrence{fingerprint: event.Fingerprint})
+		d.seen[event.Fingerprint] = element
+		if d.recent.Len() > maxFingerprints {
+			d.evict()
+		}
+	}
+	seen := element.Value.(*occurrence)
+	seen.count++
+	if exist && event.Time.Sub(seen.reportedAt) < d.window {
+		droppedCounter.WithLabelValues("duplicate").Inc()
+		return false
+	}
+	event.Count = seen.count
+	seen.count = 0
+	seen.reportedAt = event.Time
+	return true
+}
+
+// evict forgets the least recently seen fingerprint, its pending duplicates are not reported
+func (d *Dispatcher) evict() {
+	oldest := d.recent.Remove(d.recent.Back()).(*occurrence)
+	delete(d.seen, oldest.fingerprint)
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	for {
+		select {
+		case event := <-d.queue:
+			d.report(event)
+		case <-d.stop:
+			for {
+				select {
+				case event := <-d.queue:
+					d.report(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) report(event Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+	for _, reporter := range d.reporters {
+		if err := reporter.Report(ctx, event); err != nil {
+			droppedCounter.WithLabelValues("error").Inc()
+			// logged at warn level, error entries would be reported again
+			logger.L().Warn("Report error has error: ", zap.Error(err), zap.String("fingerprint", event.Fingerprint))
+		}
+	}
+}
+
+// Close reports the queued events then closes the reporters, until ctx is done
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.once.Do(func() {
+		close(d.stop)
+	})
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	for _, reporter := range d.reporters {
+		if closer, ok := reporter.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+	return nil
+}
+
+func newEvent(entry zapcore.Entry, fields []zapcore.Field) Event {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	event := Event{
+		Level:   entry.Level.String(),
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+		Stack:   entry.Stack,
+		Fields:  encoder.Fields,
+		Time:    entry.Time,
+	}
+	if entry.Caller.Defined {
+		event.Caller = entry.Caller.TrimmedPath()
+	}
+	if err, ok := encoder.Fields["error"].(string); ok {
+		event.Error = err
+		delete(encoder.Fields, "error")
+	}
+	// the recover interceptor and middleware log the stack of the panic
+	if stack, ok := encoder.Fields[logger.FieldStack].(string); ok {
+		event.Stack = stack
+		delete(encoder.Fields, logger.FieldStack)
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Fingerprint = Fingerprint(event.Message, event.Stack)
+	return event
+}
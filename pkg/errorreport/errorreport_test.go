@@ -0,0 +1,106 @@
+package errorreport
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDispatcherWindow(t *testing.T) {
+	reporter := NewMemoryReporter()
+	dispatcher := NewDispatcher(time.Minute, reporter)
+	duplicates := droppedCounter.WithLabelValues("duplicate")
+	before := testutil.ToFloat64(duplicates)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := func(offset time.Duration, message string) {
+		dispatcher.Hook(zapcore.Entry{Level: zapcore.ErrorLevel, Message: message, Time: start.Add(offset)},
+			[]zapcore.Field{zap.String("method", "/acme.Payment/Charge")})
+	}
+	log(0, "boom")
+	log(10*time.Second, "boom")
+	log(20*time.Second, "other")
+	log(30*time.Second, "boom")
+	// the window of the first report is over, the duplicates are reported with this one
+	log(61*time.Second, "boom")
+	log(62*time.Second, "boom")
+
+	if err := dispatcher.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	events := reporter.Events()
+	if len(events) != 3 {
+		t.Fatalf("reported %d events, want 3: %+v", len(events), events)
+	}
+	want := []struct {
+		message string
+		count   int
+	}{{"boom", 1}, {"other", 1}, {"boom", 3}}
+	for i, event := range events {
+		if event.Message != want[i].message || event.Count != want[i].count {
+			t.Errorf("event %d = %s x%d, want %s x%d", i, event.Message, event.Count, want[i].message, want[i].count)
+		}
+	}
+	if events[0].Fingerprint != events[2].Fingerprint || events[0].Fingerprint == events[1].Fingerprint {
+		t.Error("events should be grouped by message")
+	}
+	if got := testutil.ToFloat64(duplicates) - before; got != 3 {
+		t.Errorf("duplicates = %v, want 3", got)
+	}
+}
+
+func TestDispatcherEventFields(t *testing.T) {
+	reporter := NewMemoryReporter()
+	dispatcher := NewDispatcher(time.Minute, reporter)
+	dispatcher.Hook(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "Response: ", Time: time.Now()},
+		[]zapcore.Field{zap.Error(context.DeadlineExceeded), zap.String("stack", testStack), zap.Int("attempt", 2)})
+	if err := dispatcher.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	events := reporter.Events()
+	if len(events) != 1 {
+		t.Fatalf("reported %d events, want 1", len(events))
+	}
+	event := events[0]
+	if event.Error != context.DeadlineExceeded.Error() || event.Stack != testStack {
+		t.Errorf("error = %q, stack = %q", event.Error, event.Stack)
+	}
+	if _, exist := event.Fields["error"]; exist {
+		t.Error("error should be moved out of the fields")
+	}
+	if event.Fields["attempt"] != int64(2) {
+		t.Errorf("fields = %v", event.Fields)
+	}
+}
+
+func TestDispatcherFingerprintLimit(t *testing.T) {
+	dispatcher := NewDispatcher(time.Hour)
+	t.Cleanup(func() { _ = dispatcher.Close(context.Background()) })
+
+	now := time.Now()
+	admit := func(fingerprint string) bool {
+		return dispatcher.admit(&Event{Fingerprint: fingerprint, Time: now})
+	}
+	admit("recent")
+	admit("old")
+	for i := 0; i < maxFingerprints; i++ {
+		admit(strconv.Itoa(i))
+		if i == maxFingerprints/2 {
+			admit("recent")
+		}
+	}
+	if got := len(dispatcher.seen); got != maxFingerprints {
+		t.Errorf("%d fingerprints remembered, want %d", got, maxFingerprints)
+	}
+	if admit("recent") {
+		t.Error("a recently seen fingerprint should be remembered")
+	}
+	if !admit("old") {
+		t.Error("the least recently seen fingerprint should be forgotten")
+	}
+}
@@ -0,0 +1,112 @@
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// FileReporter appends the events to a file, one JSON object per line
+type FileReporter struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func NewFileReporter(path string) (*FileReporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileReporter{file: file}, nil
+}
+
+func (r *FileReporter) Report(_ context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, err = r.file.Write(append(line, '\n'))
+	return err
+}
+
+func (r *FileReporter) Close() error {
+	return r.file.Close()
+}
+
+// WebhookReporter posts the events as JSON to url
+type WebhookReporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewWebhookReporter(url string, headers map[string]string) *WebhookReporter {
+	return &WebhookReporter{url: url, headers: headers, client: http.DefaultClient}
+}
+
+func (r *WebhookReporter) Report(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return post(ctx, r.client, r.url, r.headers, body)
+}
+
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%s responded %s", url, response.Status)
+	}
+	return nil
+}
+
+// MemoryReporter keeps the events in memory, for tests
+type MemoryReporter struct {
+	mutex  sync.Mutex
+	events []Event
+}
+
+func NewMemoryReporter() *MemoryReporter {
+	return &MemoryReporter{}
+}
+
+func (r *MemoryReporter) Report(_ context.Context, event Event) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// Events returns the reported events, in order
+func (r *MemoryReporter) Events() []Event {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// Reset drops the reported events
+func (r *MemoryReporter) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = nil
+}
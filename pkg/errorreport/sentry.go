@@ -0,0 +1,150 @@
+package errorreport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const sentryClient = "go-common/1.0"
+
+// SentryReporter sends the events to the store endpoint of a Sentry compatible server
+// (Sentry, GlitchTip, ...). The fingerprint groups the events into issues
+type SentryReporter struct {
+	storeURL    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+}
+
+// NewSentryReporter parses dsn, formatted as {scheme}://{key}@{host}/{path/}{project_id}
+func NewSentryReporter(dsn, environment, release string) (*SentryReporter, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.User == nil || len(parsed.User.Username()) == 0 {
+		return nil, fmt.Errorf("sentry dsn has no public key")
+	}
+	path := strings.TrimSuffix(parsed.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if i < 0 || i == len(path)-1 {
+		return nil, fmt.Errorf("sentry dsn has no project id")
+	}
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", sentryClient, parsed.User.Username())
+	if secret, ok := parsed.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	serverName, _ := os.Hostname()
+	return &SentryReporter{
+		storeURL:    fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, path[:i], path[i+1:]),
+		auth:        auth,
+		environment: environment,
+		release:     release,
+		serverName:  serverName,
+		client:      http.DefaultClient,
+	}, nil
+}
+
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger,omitempty"`
+	Platform    string                 `json:"platform"`
+	Message     string                 `json:"message"`
+	Culprit     string                 `json:"culprit,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Fingerprint []string               `json:"fingerprint"`
+	Exception   *sentryExceptions      `json:"exception,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno,omitempty"`
+}
+
+func (r *SentryReporter) Report(ctx context.Context, event Event) error {
+	body, err := json.Marshal(r.toSentry(event))
+	if err != nil {
+		return err
+	}
+	return post(ctx, r.client, r.storeURL, map[string]string{"X-Sentry-Auth": r.auth}, body)
+}
+
+func (r *SentryReporter) toSentry(event Event) sentryEvent {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	extra := make(map[string]interface{}, len(event.Fields)+1)
+	for key, value := range event.Fields {
+		extra[key] = value
+	}
+	extra["count"] = event.Count
+	converted := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   event.Time.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		Level:       sentryLevel(event.Level),
+		Logger:      event.Logger,
+		Platform:    "go",
+		Message:     event.Message,
+		Culprit:     event.Caller,
+		ServerName:  r.serverName,
+		Environment: r.environment,
+		Release:     r.release,
+		Fingerprint: []string{event.Fingerprint},
+		Extra:       extra,
+	}
+	frames := ParseStack(event.Stack)
+	if len(event.Error) > 0 || len(frames) > 0 {
+		exception := sentryException{Type: event.Message, Value: event.Error}
+		if len(frames) > 0 {
+			// sentry expects the outermost call first
+			stacktrace := &sentryStacktrace{Frames: make([]sentryFrame, 0, len(frames))}
+			for i := len(frames) - 1; i >= 0; i-- {
+				stacktrace.Frames = append(stacktrace.Frames, sentryFrame{
+					Function: frames[i].Function,
+					Filename: frames[i].File,
+					Lineno:   frames[i].Line,
+				})
+			}
+			exception.Stacktrace = stacktrace
+		}
+		converted.Exception = &sentryExceptions{Values: []sentryException{exception}}
+	}
+	return converted
+}
+
+func sentryLevel(level string) string {
+	switch level {
+	case "dpanic", "panic", "fatal":
+		return "fatal"
+	default:
+		return level
+	}
+}
@@ -0,0 +1,75 @@
+package errorreport
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Frame is a function call of a stack trace
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// ParseStack reads the frames of a stack trace formatted by runtime/debug.Stack or zap,
+// innermost call first
+func ParseStack(stack string) []Frame {
+	var frames []Frame
+	for _, line := range strings.Split(stack, "\n") {
+		if len(line) == 0 || strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			function := line
+			// debug.Stack prints the arguments, e.g. main.handle(0xc000010000, 0x1)
+			if i := strings.LastIndex(function, "("); i > 0 && strings.HasSuffix(function, ")") {
+				function = function[:i]
+			}
+			// go1.21+ prints the creator goroutine, e.g. created by main.main in goroutine 1
+			if i := strings.Index(function, " in goroutine "); i > 0 {
+				function = function[:i]
+			}
+			frames = append(frames, Frame{Function: function})
+			continue
+		}
+		if len(frames) == 0 {
+			continue
+		}
+		location := strings.TrimSpace(line)
+		if i := strings.LastIndex(location, " +0x"); i > 0 {
+			location = location[:i]
+		}
+		frame := &frames[len(frames)-1]
+		frame.File = location
+		if i := strings.LastIndex(location, ":"); i > 0 {
+			if number, err := strconv.Atoi(location[i+1:]); err == nil {
+				frame.File, frame.Line = location[:i], number
+			}
+		}
+	}
+	return frames
+}
+
+// Fingerprint groups the errors with the same message raised from the same functions.
+// Line numbers are ignored so that a fingerprint survives unrelated changes of the code
+func Fingerprint(message, stack string) string {
+	hash := sha1.New()
+	hash.Write([]byte(message))
+	for _, frame := range ParseStack(stack) {
+		if isRuntimeFrame(frame.Function) {
+			continue
+		}
+		hash.Write([]byte{'\n'})
+		hash.Write([]byte(frame.Function))
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// isRuntimeFrame tells whether function is part of the panic and stack capture machinery
+func isRuntimeFrame(function string) bool {
+	return strings.HasPrefix(function, "runtime.") || strings.HasPrefix(function, "runtime/debug.") ||
+		function == "panic"
+}
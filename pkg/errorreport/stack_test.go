@@ -0,0 +1,51 @@
+package errorreport
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testStack = `goroutine 7 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:24 +0x5e
+github.com/acme/app.(*Handler).Charge(0xc000010000, {0x1, 0x2})
+	/app/handler.go:42 +0x25
+created by github.com/acme/app.Serve in goroutine 1
+	/app/server.go:20 +0x30
+`
+
+func TestParseStack(t *testing.T) {
+	want := []Frame{
+		{Function: "runtime/debug.Stack", File: "/usr/local/go/src/runtime/debug/stack.go", Line: 24},
+		{Function: "github.com/acme/app.(*Handler).Charge", File: "/app/handler.go", Line: 42},
+		{Function: "created by github.com/acme/app.Serve", File: "/app/server.go", Line: 20},
+	}
+	if got := ParseStack(testStack); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseStack() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := Fingerprint("Response: ", testStack)
+
+	otherLines := `github.com/acme/app.(*Handler).Charge(0xc000020000)
+	/app/handler.go:50 +0x25
+created by github.com/acme/app.Serve in goroutine 3
+	/app/server.go:21 +0x30
+`
+	if got := Fingerprint("Response: ", otherLines); got != fingerprint {
+		t.Errorf("fingerprint %s, want %s: lines and runtime frames are ignored", got, fingerprint)
+	}
+	otherFunction := `github.com/acme/app.(*Handler).Refund(0xc000020000)
+	/app/handler.go:42 +0x25
+`
+	for name, got := range map[string]string{
+		"message":  Fingerprint("Request: ", testStack),
+		"function": Fingerprint("Response: ", otherFunction),
+		"stack":    Fingerprint("Response: ", ""),
+	} {
+		if got == fingerprint {
+			t.Errorf("%s: the fingerprint should change", name)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+const AuthorizationMetadataKey = "authorization"
+
+type clientKeyCredentials struct {
+	clientId   string
+	clientKey  string
+	requireTLS bool
+}
+
+// NewClientKeyCredentials sends client-id and client-key with every call, as checked by NewAuthenUnaryServerInterceptor
+func NewClientKeyCredentials(clientId, clientKey string, requireTLS bool) credentials.PerRPCCredentials {
+	return &clientKeyCredentials{
+		clientId:   clientId,
+		clientKey:  clientKey,
+		requireTLS: requireTLS,
+	}
+}
+
+func (c *clientKeyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		ClientIdMetadataKey:  c.clientId,
+		ClientKeyMetadataKey: c.clientKey,
+	}, nil
+}
+
+func (c *clientKeyCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// TokenSource returns a new token and its expiry time
+type TokenSource func(ctx context.Context) (token string, expiry time.Time, err error)
+
+type bearerTokenCredentials struct {
+	source        TokenSource
+	refreshBefore time.Duration
+	requireTLS    bool
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewBearerTokenCredentials sends `authorization: Bearer <token>` with every call.
+// The token is cached and refreshed from source refreshBefore its expiry
+func NewBearerTokenCredentials(source TokenSource, refreshBefore time.Duration, requireTLS bool) credentials.PerRPCCredentials {
+	return &bearerTokenCredentials{
+		source:        source,
+		refreshBefore: refreshBefore,
+		requireTLS:    requireTLS,
+	}
+}
+
+func (c *bearerTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.token) == 0 || time.Now().Add(c.refreshBefore).After(c.expiry) {
+		token, expiry, err := c.source(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.token, c.expiry = token, expiry
+	}
+	return map[string]string{
+		AuthorizationMetadataKey: "Bearer " + c.token,
+	}, nil
+}
+
+func (c *bearerTokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
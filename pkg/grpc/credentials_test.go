@@ -0,0 +1,114 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serveHealth serves the health service with interceptor on bufconn until t ends and returns its client
+func serveHealth(t *testing.T, interceptor grpc.UnaryServerInterceptor, opts ...grpc.DialOption) grpc_health_v1.HealthClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestBearerTokenCredentials(t *testing.T) {
+	var calls int
+	source := func(ctx context.Context) (string, time.Time, error) {
+		calls++
+		return "token-" + strconv.Itoa(calls), time.Now().Add(time.Hour), nil
+	}
+	perRPCCredentials := NewBearerTokenCredentials(source, time.Minute, false)
+	authorizations := make(chan string, 10)
+	client := serveHealth(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestMetadata, _ := metadata.FromIncomingContext(ctx)
+		authorizations <- requestMetadata.Get(AuthorizationMetadataKey)[0]
+		return handler(ctx, req)
+	}, grpc.WithPerRPCCredentials(perRPCCredentials))
+
+	check := func(want string) {
+		t.Helper()
+		if _, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := <-authorizations; got != want {
+			t.Errorf("authorization = %q, want %q", got, want)
+		}
+	}
+	check("Bearer token-1")
+	check("Bearer token-1")
+	// the token expires within refreshBefore
+	bearer := perRPCCredentials.(*bearerTokenCredentials)
+	bearer.mu.Lock()
+	bearer.expiry = time.Now().Add(30 * time.Second)
+	bearer.mu.Unlock()
+	check("Bearer token-2")
+	check("Bearer token-2")
+	if calls != 2 {
+		t.Errorf("source called %d times, want 2", calls)
+	}
+}
+
+func TestBearerTokenCredentialsSourceError(t *testing.T) {
+	perRPCCredentials := NewBearerTokenCredentials(func(ctx context.Context) (string, time.Time, error) {
+		return "", time.Time{}, errors.New("unauthorized client")
+	}, time.Minute, false)
+	if _, err := perRPCCredentials.GetRequestMetadata(context.Background()); err == nil {
+		t.Error("GetRequestMetadata() should fail with the source")
+	}
+}
+
+func TestRequireTransportSecurity(t *testing.T) {
+	source := func(ctx context.Context) (string, time.Time, error) {
+		return "token", time.Now().Add(time.Hour), nil
+	}
+	for name, perRPCCredentials := range map[string]credentials.PerRPCCredentials{
+		"bearer":     NewBearerTokenCredentials(source, time.Minute, true),
+		"client key": NewClientKeyCredentials("id", "key", true),
+	} {
+		if !perRPCCredentials.RequireTransportSecurity() {
+			t.Errorf("%s: RequireTransportSecurity() = false", name)
+		}
+		// the credentials are not sent over an insecure connection
+		_, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithPerRPCCredentials(perRPCCredentials))
+		if err == nil {
+			t.Errorf("%s: Dial() over an insecure connection should fail", name)
+		}
+	}
+	for name, perRPCCredentials := range map[string]credentials.PerRPCCredentials{
+		"bearer":     NewBearerTokenCredentials(source, time.Minute, false),
+		"client key": NewClientKeyCredentials("id", "key", false),
+	} {
+		if perRPCCredentials.RequireTransportSecurity() {
+			t.Errorf("%s: RequireTransportSecurity() = true", name)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+const (
+	DeadlineSideServer = "server"
+	DeadlineSideClient = "client"
+)
+
+var deadlineBudgetExhaustedCounter = metrics.Shared(prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "grpc_deadline_budget_exhausted_total",
+	Help: "Number of gRPC calls whose deadline budget was exhausted",
+}, []string{"side", "target"})).(*prometheus.CounterVec)
+
+// NewTimeoutUnaryServerInterceptor enforces a timeout on every handler. The timeout of a method is looked up
+// in methodTimeouts (lower case full method name), falling back to defaultTimeout. A shorter deadline sent by
+// the client is kept as is. A zero timeout disables the enforcement.
+func NewTimeoutUnaryServerInterceptor(defaultTimeout time.Duration, methodTimeouts map[string]time.Duration) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, HealthCheckPrefix) {
+			//skip for health check
+			return handler(ctx, req)
+		}
+		timeout := methodTimeout(info.FullMethod, defaultTimeout, methodTimeouts)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		resp, err := handler(ctx, req)
+		if ctx.Err() == context.DeadlineExceeded {
+			ReportDeadlineBudgetExhausted(ctx, DeadlineSideServer, info.FullMethod)
+		}
+		return resp, err
+	}
+}
+
+func NewTimeoutStreamServerInterceptor(defaultTimeout time.Duration, methodTimeouts map[string]time.Duration) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if strings.HasPrefix(info.FullMethod, HealthCheckPrefix) {
+			//skip for health check, Watch is a long-lived stream
+			return handler(srv, ss)
+		}
+		timeout := methodTimeout(info.FullMethod, defaultTimeout, methodTimeouts)
+		if timeout <= 0 {
+			return handler(srv, ss)
+		}
+		ctx, cancel := context.WithTimeout(ss.Context(), timeout)
+		defer cancel()
+		err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+		if ctx.Err() == context.DeadlineExceeded {
+			ReportDeadlineBudgetExhausted(ctx, DeadlineSideServer, info.FullMethod)
+		}
+		return err
+	}
+}
+
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func methodTimeout(fullMethod string, defaultTimeout time.Duration, methodTimeouts map[string]time.Duration) time.Duration {
+	if timeout, exist := methodTimeouts[strings.ToLower(fullMethod)]; exist {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// DeadlineBudget returns the timeout of an outbound call, which is
+// min(remaining deadline of ctx - safetyMargin, configured).
+// The second return value is false when the remaining budget is already exhausted.
+func DeadlineBudget(ctx context.Context, configured, safetyMargin time.Duration) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return configured, true
+	}
+	remaining := time.Until(deadline) - safetyMargin
+	if remaining <= 0 {
+		return 0, false
+	}
+	if configured > 0 && configured < remaining {
+		return configured, true
+	}
+	return remaining, true
+}
+
+// ReportDeadlineBudgetExhausted records the exhaustion as a metric and as an event of the current span
+func ReportDeadlineBudgetExhausted(ctx context.Context, side, target string) {
+	deadlineBudgetExhaustedCounter.WithLabelValues(side, target).Inc()
+	trace.SpanFromContext(ctx).AddEvent("deadline budget exhausted", trace.WithAttributes(
+		attribute.String("side", side),
+		attribute.String("target", target),
+	))
+}
@@ -0,0 +1,139 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+)
+
+const testMethod = "/pkg.Service/Get"
+
+// handlerTimeout returns the time left to the handler called by the timeout interceptor, 0 without deadline
+func handlerTimeout(t *testing.T, ctx context.Context, method string, defaultTimeout time.Duration, methodTimeouts map[string]time.Duration) time.Duration {
+	t.Helper()
+	var timeout time.Duration
+	_, err := NewTimeoutUnaryServerInterceptor(defaultTimeout, methodTimeouts)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			if deadline, ok := ctx.Deadline(); ok {
+				timeout = time.Until(deadline)
+			}
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return timeout
+}
+
+func assertAbout(t *testing.T, name string, got, want time.Duration) {
+	t.Helper()
+	if got > want || got < want-100*time.Millisecond {
+		t.Errorf("%s: timeout = %v, want about %v", name, got, want)
+	}
+}
+
+func TestTimeoutUnaryServerInterceptor(t *testing.T) {
+	methodTimeouts := map[string]time.Duration{"/pkg.service/get": 5 * time.Second, "/pkg.service/list": 0}
+	ctx := context.Background()
+
+	assertAbout(t, "default", handlerTimeout(t, ctx, "/pkg.Service/Update", 2*time.Second, methodTimeouts), 2*time.Second)
+	assertAbout(t, "method", handlerTimeout(t, ctx, testMethod, 2*time.Second, methodTimeouts), 5*time.Second)
+	if got := handlerTimeout(t, ctx, "/pkg.Service/List", 2*time.Second, methodTimeouts); got != 0 {
+		t.Errorf("disabled: timeout = %v, want none", got)
+	}
+	if got := handlerTimeout(t, ctx, HealthCheckPrefix+"/Check", 2*time.Second, nil); got != 0 {
+		t.Errorf("health check: timeout = %v, want none", got)
+	}
+
+	// the shorter deadline of the client is kept
+	clientCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assertAbout(t, "client deadline", handlerTimeout(t, clientCtx, testMethod, 2*time.Second, methodTimeouts), time.Second)
+}
+
+func TestTimeoutUnaryServerInterceptorExhausted(t *testing.T) {
+	exhausted := deadlineBudgetExhaustedCounter.WithLabelValues(DeadlineSideServer, testMethod)
+	before := testutil.ToFloat64(exhausted)
+	_, err := NewTimeoutUnaryServerInterceptor(10*time.Millisecond, nil)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: testMethod},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	if err != context.DeadlineExceeded {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := testutil.ToFloat64(exhausted) - before; got != 1 {
+		t.Errorf("exhausted budgets = %v, want 1", got)
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestTimeoutStreamServerInterceptor(t *testing.T) {
+	interceptor := NewTimeoutStreamServerInterceptor(2*time.Second, map[string]time.Duration{"/pkg.service/get": 0})
+	streamTimeout := func(method string) time.Duration {
+		var timeout time.Duration
+		err := interceptor(nil, &contextStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: method},
+			func(srv interface{}, stream grpc.ServerStream) error {
+				if deadline, ok := stream.Context().Deadline(); ok {
+					timeout = time.Until(deadline)
+				}
+				return nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return timeout
+	}
+	assertAbout(t, "default", streamTimeout("/pkg.Service/Watch"), 2*time.Second)
+	if got := streamTimeout(testMethod); got != 0 {
+		t.Errorf("disabled: timeout = %v, want none", got)
+	}
+	if got := streamTimeout(HealthCheckPrefix + "/Watch"); got != 0 {
+		t.Errorf("health watch: timeout = %v, want none", got)
+	}
+}
+
+func TestDeadlineBudget(t *testing.T) {
+	withTimeout := func(timeout time.Duration) context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		t.Cleanup(cancel)
+		return ctx
+	}
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		configured   time.Duration
+		safetyMargin time.Duration
+		want         time.Duration
+		ok           bool
+	}{
+		{name: "no deadline", ctx: context.Background(), configured: time.Second, want: time.Second, ok: true},
+		{name: "configured is shorter", ctx: withTimeout(10 * time.Second), configured: time.Second, safetyMargin: time.Second, want: time.Second, ok: true},
+		{name: "remaining is shorter", ctx: withTimeout(3 * time.Second), configured: 5 * time.Second, safetyMargin: time.Second, want: 2 * time.Second, ok: true},
+		{name: "not configured", ctx: withTimeout(3 * time.Second), safetyMargin: time.Second, want: 2 * time.Second, ok: true},
+		{name: "exhausted by the margin", ctx: withTimeout(time.Second), configured: 5 * time.Second, safetyMargin: 2 * time.Second, ok: false},
+		{name: "expired", ctx: withTimeout(-time.Second), configured: 5 * time.Second, ok: false},
+	}
+	for _, test := range tests {
+		budget, ok := DeadlineBudget(test.ctx, test.configured, test.safetyMargin)
+		if ok != test.ok {
+			t.Errorf("%s: ok = %v, want %v", test.name, ok, test.ok)
+		}
+		if test.ok {
+			assertAbout(t, test.name, budget, test.want)
+		} else if budget != 0 {
+			t.Errorf("%s: budget = %v, want 0", test.name, budget)
+		}
+	}
+}
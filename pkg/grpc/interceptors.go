@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"runtime/debug"
 	"strings"
 
 	"github.com/nmtri1912/go-common/pkg/logger"
@@ -15,6 +16,8 @@ import (
 
 const ClientIdMetadataKey = "client-id"
 const ClientKeyMetadataKey = "client-key"
+const RequestIdMetadataKey = "request-id"
+const UserIdMetadataKey = "user-id"
 
 func NewAuthenUnaryServerInterceptor(clients map[string]string, methodClients map[string][]string) grpc.UnaryServerInterceptor {
 	return func(
@@ -23,32 +26,65 @@ func NewAuthenUnaryServerInterceptor(clients map[string]string, methodClients ma
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		if strings.HasPrefix(info.FullMethod, HealthCheckPrefix) {
-			//skip for health check
-			return handler(ctx, req)
-		}
-		requestMetadata, _ := metadata.FromIncomingContext(ctx)
-		clientId, clientKey := requestMetadata.Get(ClientIdMetadataKey), requestMetadata.Get(ClientKeyMetadataKey)
-		if len(clientId) <= 0 || len(clientKey) <= 0 {
-			return nil, status.Error(codes.Unauthenticated, "client-id or client-key not present in metadata")
-		}
-		serverClientKey, exist := clients[clientId[0]]
-		if !exist {
-			return nil, status.Error(codes.Unauthenticated, "client-id not found")
-		}
-		if serverClientKey != clientKey[0] {
-			return nil, status.Error(codes.Unauthenticated, "client-key mismatch")
+		clientId, err := authenticate(ctx, info.FullMethod, clients, methodClients)
+		if err != nil {
+			logger.Ctx(ctx).Warn("Unauthenticated request", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, err
 		}
-		method := strings.ToLower(info.FullMethod)
-		allowedClients, exist := methodClients[method]
-		if exist && !contains(allowedClients, clientId[0]) {
-			return nil, status.Error(codes.Unauthenticated, "client-id not allowed")
+		if len(clientId) > 0 {
+			ctx = logger.WithFields(ctx, zap.String(logger.FieldClientId, clientId))
 		}
 		resp, err := handler(ctx, req)
 		return resp, err
 	}
 }
 
+func NewAuthenStreamServerInterceptor(clients map[string]string, methodClients map[string][]string) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		clientId, err := authenticate(ss.Context(), info.FullMethod, clients, methodClients)
+		if err != nil {
+			logger.Ctx(ss.Context()).Warn("Unauthenticated request", zap.String("method", info.FullMethod), zap.Error(err))
+			return err
+		}
+		if len(clientId) > 0 {
+			ctx := logger.WithFields(ss.Context(), zap.String(logger.FieldClientId, clientId))
+			ss = &contextServerStream{ServerStream: ss, ctx: ctx}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// authenticate returns the client-id of the request, empty for health checks
+func authenticate(ctx context.Context, fullMethod string, clients map[string]string, methodClients map[string][]string) (string, error) {
+	if strings.HasPrefix(fullMethod, HealthCheckPrefix) {
+		//skip for health check
+		return "", nil
+	}
+	requestMetadata, _ := metadata.FromIncomingContext(ctx)
+	clientId, clientKey := requestMetadata.Get(ClientIdMetadataKey), requestMetadata.Get(ClientKeyMetadataKey)
+	if len(clientId) <= 0 || len(clientKey) <= 0 {
+		return "", status.Error(codes.Unauthenticated, "client-id or client-key not present in metadata")
+	}
+	serverClientKey, exist := clients[clientId[0]]
+	if !exist {
+		return "", status.Error(codes.Unauthenticated, "client-id not found")
+	}
+	if serverClientKey != clientKey[0] {
+		return "", status.Error(codes.Unauthenticated, "client-key mismatch")
+	}
+	method := strings.ToLower(fullMethod)
+	allowedClients, exist := methodClients[method]
+	if exist && !contains(allowedClients, clientId[0]) {
+		return "", status.Error(codes.Unauthenticated, "client-id not allowed")
+	}
+	return clientId[0], nil
+}
+
 func NewLoggingUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -61,6 +97,7 @@ func NewLoggingUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 			return handler(ctx, req)
 		}
 		requestMetadata, _ := metadata.FromIncomingContext(ctx)
+		ctx = logger.WithFields(ctx, metadataFields(requestMetadata)...)
 		logger.Ctx(ctx).Info("Request: ",
 			zap.String("method", info.FullMethod),
 			zap.Reflect("metadata", requestMetadata),
@@ -81,6 +118,18 @@ func NewLoggingUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	}
 }
 
+// metadataFields returns the standard log fields present in the request metadata
+func metadataFields(requestMetadata metadata.MD) []zap.Field {
+	var fields []zap.Field
+	if requestId := requestMetadata.Get(RequestIdMetadataKey); len(requestId) > 0 {
+		fields = append(fields, zap.String(logger.FieldRequestId, requestId[0]))
+	}
+	if userId := requestMetadata.Get(UserIdMetadataKey); len(userId) > 0 {
+		fields = append(fields, zap.String(logger.FieldUserId, userId[0]))
+	}
+	return fields
+}
+
 func contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -96,7 +145,8 @@ func NewRecoverUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 		defer func() {
 			if r := recover(); r != nil || panicked {
 				err = status.Errorf(codes.Internal, "%v", r)
-				logger.Ctx(ctx).Error("Response: ", zap.Error(err))
+				logger.Ctx(ctx).Error("Response: ", zap.Error(err), zap.String("method", info.FullMethod),
+					zap.Any(logger.FieldPanic, r), zap.String(logger.FieldStack, string(debug.Stack())))
 			}
 		}()
 		resp, err := handler(ctx, req)
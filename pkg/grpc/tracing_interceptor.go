@@ -52,9 +52,27 @@ var (
 	messageReceived = messageType(otelgrpc.RPCMessageTypeReceived)
 )
 
+// TracingOption configures the tracing interceptor
+type TracingOption func(*tracingConfig)
+
+type tracingConfig struct {
+	provider trace.TracerProvider
+}
+
+// WithTracerProvider starts the spans with provider instead of the global one, e.g. in tests
+func WithTracerProvider(provider trace.TracerProvider) TracingOption {
+	return func(config *tracingConfig) {
+		config.provider = provider
+	}
+}
+
 // UnaryServerInterceptor returns a grpc.UnaryServerInterceptor suitable
 // for use in a grpc.NewServer call.
-func NewTracingUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+func NewTracingUnaryServerInterceptor(opts ...TracingOption) grpc.UnaryServerInterceptor {
+	config := &tracingConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -71,7 +89,11 @@ func NewTracingUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 		bags, spanCtx := otelgrpc.Extract(ctx, &metadataCopy)
 		ctx = baggage.ContextWithBaggage(ctx, bags)
 
-		tracer := otel.GetTracerProvider().Tracer(
+		provider := config.provider
+		if provider == nil {
+			provider = otel.GetTracerProvider()
+		}
+		tracer := provider.Tracer(
 			instrumentationName,
 			trace.WithInstrumentationVersion(otelgrpc.SemVersion()),
 		)
@@ -0,0 +1,216 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/nmtri1912/go-common/utils/errorutils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const ValidationErrorReason = "VALIDATION_FAILED"
+
+// ProtoValidateFunc validates a message against protovalidate-style constraints,
+// e.g. a closure around (*protovalidate.Validator).Validate
+type ProtoValidateFunc func(msg proto.Message) error
+
+// validator is implemented by messages generated by protoc-gen-validate
+type validator interface {
+	Validate() error
+}
+
+// allValidator is implemented by messages generated by protoc-gen-validate (>= 0.6.0)
+type allValidator interface {
+	ValidateAll() error
+}
+
+// pgvFieldError is implemented by every <Message>ValidationError of protoc-gen-validate
+type pgvFieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// pgvMultiError is implemented by every <Message>MultiError of protoc-gen-validate
+type pgvMultiError interface {
+	AllErrors() []error
+}
+
+func NewValidationUnaryServerInterceptor(domain string, protoValidate ProtoValidateFunc) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := validateMessage(req, domain, protoValidate); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func NewValidationStreamServerInterceptor(domain string, protoValidate ProtoValidateFunc) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &validatingServerStream{
+			ServerStream:  ss,
+			domain:        domain,
+			protoValidate: protoValidate,
+		})
+	}
+}
+
+type validatingServerStream struct {
+	grpc.ServerStream
+	domain        string
+	protoValidate ProtoValidateFunc
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateMessage(m, s.domain, s.protoValidate)
+}
+
+func validateMessage(req interface{}, domain string, protoValidate ProtoValidateFunc) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	var message string
+
+	switch v := req.(type) {
+	case allValidator:
+		if err := v.ValidateAll(); err != nil {
+			message = err.Error()
+			violations = pgvViolations("", err)
+		}
+	case validator:
+		if err := v.Validate(); err != nil {
+			message = err.Error()
+			violations = pgvViolations("", err)
+		}
+	}
+
+	if protoValidate != nil {
+		if msg, ok := req.(proto.Message); ok {
+			if err := protoValidate(msg); err != nil {
+				if len(message) == 0 {
+					message = err.Error()
+				}
+				violations = append(violations, protovalidateViolations(err)...)
+			}
+		}
+	}
+
+	if len(message) == 0 && len(violations) == 0 {
+		return nil
+	}
+	return errorutils.NewGrpcBadRequestError(message, ValidationErrorReason, domain, violations)
+}
+
+// pgvViolations flattens protoc-gen-validate errors, including embedded message errors, into field violations
+func pgvViolations(prefix string, err error) []*errdetails.BadRequest_FieldViolation {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if multi, ok := err.(pgvMultiError); ok {
+		for _, e := range multi.AllErrors() {
+			violations = append(violations, pgvViolations(prefix, e)...)
+		}
+		return violations
+	}
+	fieldErr, ok := err.(pgvFieldError)
+	if !ok {
+		return nil
+	}
+	field := joinFieldPath(prefix, fieldErr.Field())
+	if cause := fieldErr.Cause(); cause != nil {
+		if nested := pgvViolations(field, cause); len(nested) > 0 {
+			return nested
+		}
+	}
+	return []*errdetails.BadRequest_FieldViolation{{
+		Field:       field,
+		Description: fieldErr.Reason(),
+	}}
+}
+
+// protovalidateViolations reads the violations of a protovalidate ValidationError through its
+// ToProto() method, so that this package does not depend on a specific protovalidate version
+func protovalidateViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	for ; err != nil; err = errors.Unwrap(err) {
+		method := reflect.ValueOf(err).MethodByName("ToProto")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			continue
+		}
+		msg, ok := method.Call(nil)[0].Interface().(proto.Message)
+		if !ok || msg == nil {
+			continue
+		}
+		return violationsFromProto(msg.ProtoReflect())
+	}
+	return nil
+}
+
+func violationsFromProto(msg protoreflect.Message) []*errdetails.BadRequest_FieldViolation {
+	fd := msg.Descriptor().Fields().ByName("violations")
+	if fd == nil || !fd.IsList() || fd.Message() == nil {
+		return nil
+	}
+	list := msg.Get(fd).List()
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		violation := list.Get(i).Message()
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       violationFieldPath(violation),
+			Description: stringField(violation, "message"),
+		})
+	}
+	return violations
+}
+
+func violationFieldPath(violation protoreflect.Message) string {
+	if path := stringField(violation, "field_path"); len(path) > 0 {
+		return path
+	}
+	fd := violation.Descriptor().Fields().ByName("field")
+	if fd == nil || fd.Message() == nil || !violation.Has(fd) {
+		return ""
+	}
+	field := violation.Get(fd).Message()
+	elementsFd := field.Descriptor().Fields().ByName("elements")
+	if elementsFd == nil || !elementsFd.IsList() || elementsFd.Message() == nil {
+		return ""
+	}
+	elements := field.Get(elementsFd).List()
+	path := ""
+	for i := 0; i < elements.Len(); i++ {
+		path = joinFieldPath(path, stringField(elements.Get(i).Message(), "field_name"))
+	}
+	return path
+}
+
+func stringField(msg protoreflect.Message, name protoreflect.Name) string {
+	fd := msg.Descriptor().Fields().ByName(name)
+	if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+		return ""
+	}
+	return msg.Get(fd).String()
+}
+
+func joinFieldPath(prefix, field string) string {
+	if len(prefix) == 0 {
+		return field
+	}
+	if len(field) == 0 {
+		return prefix
+	}
+	return strings.Join([]string{prefix, field}, ".")
+}
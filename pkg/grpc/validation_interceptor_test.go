@@ -0,0 +1,206 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nmtri1912/go-common/utils/errorutils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fieldError mimics the <Message>ValidationError of protoc-gen-validate
+type fieldError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e fieldError) Field() string  { return e.field }
+func (e fieldError) Reason() string { return e.reason }
+func (e fieldError) Cause() error   { return e.cause }
+func (e fieldError) Error() string  { return "invalid " + e.field + ": " + e.reason }
+
+// multiError mimics the <Message>MultiError of protoc-gen-validate
+type multiError []error
+
+func (m multiError) AllErrors() []error { return m }
+func (m multiError) Error() string      { return m[0].Error() }
+
+type pgvRequest struct {
+	err error
+}
+
+func (r *pgvRequest) Validate() error { return r.err }
+
+type pgvAllRequest struct {
+	pgvRequest
+	all error
+}
+
+func (r *pgvAllRequest) ValidateAll() error { return r.all }
+
+type fieldViolation struct {
+	field       string
+	description string
+}
+
+// violations returns the field violations of an InvalidArgument error
+func violations(t *testing.T, err error) []fieldViolation {
+	t.Helper()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("error = %v, want InvalidArgument", err)
+	}
+	var result []fieldViolation
+	for _, violation := range errorutils.ExtractFieldViolationsFromError(err) {
+		result = append(result, fieldViolation{violation.GetField(), violation.GetDescription()})
+	}
+	return result
+}
+
+func callValidation(req interface{}, protoValidate ProtoValidateFunc) (bool, error) {
+	handled := false
+	_, err := NewValidationUnaryServerInterceptor("acme.com", protoValidate)(context.Background(), req, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			handled = true
+			return nil, nil
+		})
+	return handled, err
+}
+
+func TestValidationUnaryServerInterceptor(t *testing.T) {
+	req := &pgvAllRequest{all: multiError{
+		fieldError{field: "name", reason: "value length must be at least 1 runes"},
+		fieldError{field: "address", reason: "embedded message failed validation",
+			cause: fieldError{field: "city", reason: "value is required"}},
+	}}
+	handled, err := callValidation(req, nil)
+	if handled {
+		t.Error("the handler should not be called")
+	}
+	want := []fieldViolation{
+		{"name", "value length must be at least 1 runes"},
+		{"address.city", "value is required"},
+	}
+	if got := violations(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+	if !strings.Contains(status.Convert(err).Message(), "name") {
+		t.Errorf("message = %q", status.Convert(err).Message())
+	}
+}
+
+func TestValidationUnaryServerInterceptorValidate(t *testing.T) {
+	handled, err := callValidation(&pgvRequest{err: fieldError{field: "id", reason: "value must be greater than 0"}}, nil)
+	if handled {
+		t.Error("the handler should not be called")
+	}
+	if got := violations(t, err); !reflect.DeepEqual(got, []fieldViolation{{"id", "value must be greater than 0"}}) {
+		t.Errorf("violations = %v", got)
+	}
+
+	handled, err = callValidation(&pgvRequest{}, nil)
+	if !handled || err != nil {
+		t.Errorf("valid request: handled = %v, error = %v", handled, err)
+	}
+}
+
+// violationsError mimics a protovalidate ValidationError, whose ToProto returns buf.validate.Violations
+type violationsError struct {
+	violations proto.Message
+}
+
+func (e *violationsError) Error() string          { return "validation error" }
+func (e *violationsError) ToProto() proto.Message { return e.violations }
+
+// newViolations builds a message shaped as buf.validate.Violations
+func newViolations(t *testing.T, pairs ...string) proto.Message {
+	t.Helper()
+	optional, repeated := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	stringType, messageType := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/violations.proto"),
+		Package: proto.String("test"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Violation"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("field_path"), Number: proto.Int32(1), Label: optional, Type: stringType},
+				{Name: proto.String("message"), Number: proto.Int32(2), Label: optional, Type: stringType},
+			},
+		}, {
+			Name: proto.String("Violations"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("violations"), Number: proto.Int32(1), Label: repeated, Type: messageType, TypeName: proto.String(".test.Violation")},
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	violationDesc, violationsDesc := file.Messages().Get(0), file.Messages().Get(1)
+	violations := dynamicpb.NewMessage(violationsDesc)
+	list := violations.Mutable(violationsDesc.Fields().ByName("violations")).List()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		violation := dynamicpb.NewMessage(violationDesc)
+		violation.Set(violationDesc.Fields().ByName("field_path"), protoreflect.ValueOfString(pairs[i]))
+		violation.Set(violationDesc.Fields().ByName("message"), protoreflect.ValueOfString(pairs[i+1]))
+		list.Append(protoreflect.ValueOfMessage(violation))
+	}
+	return violations
+}
+
+func TestValidationUnaryServerInterceptorProtoValidate(t *testing.T) {
+	protoValidate := func(msg proto.Message) error {
+		if len(msg.(*wrapperspb.StringValue).GetValue()) > 0 {
+			return nil
+		}
+		// wrapped as by callers adding context
+		return fmt.Errorf("validate: %w", &violationsError{violations: newViolations(t, "value", "value is required")})
+	}
+	handled, err := callValidation(wrapperspb.String(""), protoValidate)
+	if handled {
+		t.Error("the handler should not be called")
+	}
+	if got := violations(t, err); !reflect.DeepEqual(got, []fieldViolation{{"value", "value is required"}}) {
+		t.Errorf("violations = %v", got)
+	}
+
+	if handled, err := callValidation(wrapperspb.String("ok"), protoValidate); !handled || err != nil {
+		t.Errorf("valid request: handled = %v, error = %v", handled, err)
+	}
+}
+
+type recvServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *recvServerStream) RecvMsg(m interface{}) error {
+	return nil
+}
+
+func TestValidationStreamServerInterceptor(t *testing.T) {
+	interceptor := NewValidationStreamServerInterceptor("acme.com", nil)
+	err := interceptor(nil, &recvServerStream{}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		if err := stream.RecvMsg(&pgvRequest{}); err != nil {
+			t.Errorf("valid message: RecvMsg() = %v", err)
+		}
+		err := stream.RecvMsg(&pgvRequest{err: fieldError{field: "id", reason: "value must be greater than 0"}})
+		if got := violations(t, err); !reflect.DeepEqual(got, []fieldViolation{{"id", "value must be greater than 0"}}) {
+			t.Errorf("violations = %v", got)
+		}
+		return err
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error = %v, want InvalidArgument", err)
+	}
+}
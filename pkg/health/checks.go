@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck checks a database connection, e.g. *sql.DB
+func PingCheck(db pinger) CheckFunc {
+	return db.PingContext
+}
+
+func RedisCheck(client redis.UniversalClient) CheckFunc {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// KafkaBrokersCheck succeeds when at least one of the brokers accepts a TCP connection
+func KafkaBrokersCheck(brokers []string) CheckFunc {
+	return func(ctx context.Context) error {
+		dialer := net.Dialer{}
+		var lastErr error
+		for _, broker := range brokers {
+			conn, err := dialer.DialContext(ctx, "tcp", broker)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			_ = conn.Close()
+			return nil
+		}
+		if lastErr == nil {
+			return fmt.Errorf("no kafka broker configured")
+		}
+		return fmt.Errorf("no kafka broker reachable: %w", lastErr)
+	}
+}
@@ -0,0 +1,28 @@
+package health
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultInterval = 10 * time.Second
+	defaultTimeout  = 3 * time.Second
+)
+
+// Interval returns health.interval-sec, the period of background checks
+func Interval() time.Duration {
+	if interval := viper.GetInt("health.interval-sec"); interval > 0 {
+		return time.Duration(interval) * time.Second
+	}
+	return defaultInterval
+}
+
+// Timeout returns health.timeout-ms, the timeout of a round of checks
+func Timeout() time.Duration {
+	if timeout := viper.GetInt("health.timeout-ms"); timeout > 0 {
+		return time.Duration(timeout) * time.Millisecond
+	}
+	return defaultTimeout
+}
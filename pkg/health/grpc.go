@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GrpcReporter runs the checks periodically and reports the results to a gRPC health server:
+//   - "" (the whole server) depends on all checks
+//   - every check is reported as a service with its own name
+//   - services added by AddService depend on the given checks
+type GrpcReporter struct {
+	checker  *Checker
+	server   *health.Server
+	interval time.Duration
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	services map[string][]string
+	quit     chan struct{}
+	done     chan struct{}
+
+	startOnce    sync.Once
+	shutdownOnce sync.Once
+	closeOnce    sync.Once
+	started      bool
+	drained      bool
+}
+
+func NewGrpcReporter(checker *Checker, server *health.Server, interval, timeout time.Duration) *GrpcReporter {
+	return &GrpcReporter{
+		checker:  checker,
+		server:   server,
+		interval: interval,
+		timeout:  timeout,
+		services: map[string][]string{},
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// AddService reports service as SERVING only when all the dependencies are healthy.
+// Without dependencies, the service depends on all checks
+func (r *GrpcReporter) AddService(service string, dependencies ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.services[service] = dependencies
+}
+
+// Start updates the statuses once, then keeps updating them in background until Shutdown
+func (r *GrpcReporter) Start() {
+	r.startOnce.Do(r.start)
+}
+
+func (r *GrpcReporter) start() {
+	r.mu.Lock()
+	r.started = true
+	r.mu.Unlock()
+	r.update()
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.update()
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown stops the updates, sets all services to NOT_SERVING and marks the checker as draining until Close.
+// Only the first call has effect
+func (r *GrpcReporter) Shutdown() {
+	r.shutdownOnce.Do(func() {
+		r.mu.Lock()
+		started := r.started
+		r.mu.Unlock()
+		close(r.quit)
+		if started {
+			<-r.done
+		}
+		r.checker.Shutdown()
+		r.server.Shutdown()
+		r.mu.Lock()
+		r.drained = true
+		r.mu.Unlock()
+	})
+}
+
+// Close resumes the checker drained by Shutdown once the server is stopped,
+// so that the checker can be shared by the next servers of the process, e.g. in tests
+func (r *GrpcReporter) Close() {
+	r.closeOnce.Do(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.drained {
+			r.checker.Resume()
+		}
+	})
+}
+
+func (r *GrpcReporter) update() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	results := r.checker.Check(ctx)
+
+	for name, err := range results {
+		if err != nil {
+			logger.L().Warn("Health check failed", zap.String("check", name), zap.Error(err))
+		}
+		r.server.SetServingStatus(name, servingStatus(err == nil))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.server.SetServingStatus("", servingStatus(Healthy(results)))
+	for service, dependencies := range r.services {
+		for _, dependency := range dependencies {
+			if _, exist := results[dependency]; !exist {
+				logger.L().Warn("Unknown health dependency", zap.String("service", service), zap.String("check", dependency))
+			}
+		}
+		r.server.SetServingStatus(service, servingStatus(Healthy(results, dependencies...)))
+	}
+}
+
+func servingStatus(healthy bool) healthpb.HealthCheckResponse_ServingStatus {
+	if healthy {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
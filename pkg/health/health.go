@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+type CheckFunc func(ctx context.Context) error
+
+// Checker holds the dependency checks (MySQL, Redis, Kafka, ...) of a service.
+// It's shared by the HTTP readiness endpoint and the gRPC health server
+type Checker struct {
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+	// draining counts the Shutdown calls not yet resumed, e.g. by several servers sharing DefaultChecker
+	draining int
+}
+
+// DefaultChecker is the checker used by modulefx modules to register their dependencies
+var DefaultChecker = NewChecker()
+
+func NewChecker() *Checker {
+	return &Checker{
+		checks: map[string]CheckFunc{},
+	}
+}
+
+// Register adds a dependency check to DefaultChecker
+func Register(name string, check CheckFunc) {
+	DefaultChecker.Register(name, check)
+}
+
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Names returns the sorted names of registered checks
+func (c *Checker) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check runs all checks concurrently and returns the result of each check, nil means healthy.
+// After Shutdown, every check reports ErrShuttingDown
+func (c *Checker) Check(ctx context.Context) map[string]error {
+	c.mu.RLock()
+	checks := make(map[string]CheckFunc, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	shuttingDown := c.draining > 0
+	c.mu.RUnlock()
+
+	results := make(map[string]error, len(checks))
+	if shuttingDown {
+		for name := range checks {
+			results[name] = ErrShuttingDown
+		}
+		return results
+	}
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			err := check(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// Shutdown marks the checker as draining, so that readiness fails while in-flight requests complete.
+// It lasts until every Shutdown is followed by a Resume
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.draining++
+}
+
+// Resume ends a Shutdown, e.g. once the server that was draining is stopped
+func (c *Checker) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.draining > 0 {
+		c.draining--
+	}
+}
+
+func (c *Checker) IsShuttingDown() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.draining > 0
+}
+
+// Healthy returns true when every result of the given dependencies is nil. Empty dependencies means all results.
+// A dependency without result, e.g. a typo or a check never registered, is unhealthy
+func Healthy(results map[string]error, dependencies ...string) bool {
+	if len(dependencies) == 0 {
+		for _, err := range results {
+			if err != nil {
+				return false
+			}
+		}
+		return true
+	}
+	for _, dependency := range dependencies {
+		if err, exist := results[dependency]; !exist || err != nil {
+			return false
+		}
+	}
+	return true
+}
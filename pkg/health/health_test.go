@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthy(t *testing.T) {
+	results := map[string]error{"mysql": nil, "redis": errors.New("down")}
+	if Healthy(results) {
+		t.Error("all checks: want unhealthy")
+	}
+	if !Healthy(results, "mysql") {
+		t.Error("mysql: want healthy")
+	}
+	if Healthy(results, "redis") {
+		t.Error("redis: want unhealthy")
+	}
+	if Healthy(results, "mysq") {
+		t.Error("unknown dependency: want unhealthy")
+	}
+}
+
+func TestGrpcReporterShutdown(t *testing.T) {
+	checker := NewChecker()
+	checker.Register("mysql", func(ctx context.Context) error { return nil })
+	server := health.NewServer()
+	reporter := NewGrpcReporter(checker, server, time.Hour, time.Second)
+	reporter.AddService("svc", "mysql")
+	reporter.Start()
+	if got := servingStatusOf(t, server, "svc"); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("svc = %v, want SERVING", got)
+	}
+
+	reporter.Shutdown()
+	reporter.Shutdown()
+	if !checker.IsShuttingDown() {
+		t.Error("checker should drain after Shutdown")
+	}
+	if got := servingStatusOf(t, server, "svc"); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("svc = %v, want NOT_SERVING", got)
+	}
+
+	reporter.Close()
+	reporter.Close()
+	if checker.IsShuttingDown() {
+		t.Error("checker should be resumed after Close")
+	}
+	if err := checker.Check(context.Background())["mysql"]; err != nil {
+		t.Errorf("mysql = %v, want nil", err)
+	}
+}
+
+func TestGrpcReporterShutdownWithoutStart(t *testing.T) {
+	checker := NewChecker()
+	reporter := NewGrpcReporter(checker, health.NewServer(), time.Hour, time.Second)
+	reporter.Shutdown()
+	reporter.Close()
+	if checker.IsShuttingDown() {
+		t.Error("checker should be resumed after Close")
+	}
+}
+
+func TestCheckerSharedByServers(t *testing.T) {
+	checker := NewChecker()
+	checker.Shutdown()
+	checker.Shutdown()
+	checker.Resume()
+	if !checker.IsShuttingDown() {
+		t.Error("checker should drain until every Shutdown is resumed")
+	}
+	checker.Resume()
+	if checker.IsShuttingDown() {
+		t.Error("checker should be resumed")
+	}
+}
+
+func servingStatusOf(t *testing.T, server *health.Server, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("check %s: %v", service, err)
+	}
+	return resp.Status
+}
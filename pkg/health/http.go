@@ -0,0 +1,34 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// HTTPHandler serves the readiness: 200 when all checks pass, 503 otherwise.
+// The body contains the result of every check
+func HTTPHandler(checker *Checker, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		results := checker.Check(ctx)
+
+		body := make(map[string]string, len(results))
+		for name, err := range results {
+			if err != nil {
+				body[name] = err.Error()
+			} else {
+				body[name] = "UP"
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if Healthy(results) && !checker.IsShuttingDown() {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
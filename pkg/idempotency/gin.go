@@ -0,0 +1,90 @@
+package idempotency
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const HeaderKey = "Idempotency-Key"
+
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware deduplicates POST, PUT, PATCH and DELETE requests carrying the Idempotency-Key header.
+// Successful (2xx) responses are cached and returned to the retries, a concurrent duplicate gets 409
+func Middleware(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(HeaderKey)
+		if len(idempotencyKey) == 0 || !isMutation(c.Request.Method) {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		key := c.Request.Method + ":" + c.FullPath() + ":" + idempotencyKey
+
+		token, record, err := store.Begin(ctx, key)
+		if err != nil {
+			logger.Ctx(ctx).Error("Can not check idempotency key", zap.String("key", key), zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"message": "can not check Idempotency-Key"})
+			return
+		}
+		if len(token) == 0 {
+			if record.State != StateCompleted {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": "request with the same Idempotency-Key is in progress"})
+				return
+			}
+			c.Data(record.StatusCode, record.ContentType, record.Response)
+			c.Abort()
+			return
+		}
+
+		completed := false
+		defer func() {
+			// also when the handler panics, so that the retries are not rejected until lockTTL
+			if !completed {
+				releaseKey(ctx, store, key, token)
+			}
+		}()
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+
+		statusCode := writer.Status()
+		if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+			return
+		}
+		err = store.Complete(ctx, key, token, &Record{
+			StatusCode:  statusCode,
+			ContentType: writer.Header().Get("Content-Type"),
+			Response:    writer.body.Bytes(),
+		})
+		if err != nil {
+			logger.Ctx(ctx).Error("Can not store idempotent response", zap.String("key", key), zap.Error(err))
+		}
+		completed = err == nil
+	}
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, _ := newTestStore(t)
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, err interface{}) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.Use(Middleware(store))
+	calls := 0
+	router.POST("/orders", func(c *gin.Context) {
+		calls++
+		if c.Query("panic") == "true" {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+	post := func(url, key string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, url, nil)
+		req.Header.Set(HeaderKey, key)
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	first, retry := post("/orders", "k1"), post("/orders", "k1")
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || calls != 1 {
+		t.Errorf("retry = %d %s, calls = %d, want the cached %s", retry.Code, retry.Body, calls, first.Body)
+	}
+
+	if panicked := post("/orders?panic=true", "k2"); panicked.Code != http.StatusInternalServerError {
+		t.Fatalf("panic = %d, want 500", panicked.Code)
+	}
+	if retried := post("/orders", "k2"); retried.Code != http.StatusCreated {
+		t.Errorf("retry after a panic = %d, want the key released", retried.Code)
+	}
+}
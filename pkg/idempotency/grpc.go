@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"context"
+
+	grpc_util "github.com/nmtri1912/go-common/pkg/grpc"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const MetadataKey = "idempotency-key"
+
+// NewUnaryServerInterceptor deduplicates requests carrying the idempotency-key metadata.
+// The first request is processed and its response is cached, a retry of a completed request
+// gets the cached response and a concurrent duplicate is rejected with codes.Aborted
+func NewUnaryServerInterceptor(store *Store) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		requestMetadata, _ := metadata.FromIncomingContext(ctx)
+		idempotencyKey := requestMetadata.Get(MetadataKey)
+		if len(idempotencyKey) <= 0 || len(idempotencyKey[0]) == 0 {
+			return handler(ctx, req)
+		}
+		clientId := requestMetadata.Get(grpc_util.ClientIdMetadataKey)
+		key := info.FullMethod + ":" + idempotencyKey[0]
+		if len(clientId) > 0 {
+			key = clientId[0] + ":" + key
+		}
+
+		token, record, err := store.Begin(ctx, key)
+		if err != nil {
+			logger.Ctx(ctx).Error("Can not check idempotency key", zap.String("key", key), zap.Error(err))
+			return nil, status.Error(codes.Unavailable, "can not check idempotency-key")
+		}
+		if len(token) == 0 {
+			return cachedResponse(record)
+		}
+
+		completed := false
+		defer func() {
+			// also when the handler panics, so that the retries are not rejected until lockTTL
+			if !completed {
+				releaseKey(ctx, store, key, token)
+			}
+		}()
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		message, ok := resp.(proto.Message)
+		if !ok {
+			return resp, err
+		}
+		anyResp, storeErr := anypb.New(message)
+		if storeErr == nil {
+			var value []byte
+			value, storeErr = proto.Marshal(anyResp)
+			if storeErr == nil {
+				storeErr = store.Complete(ctx, key, token, &Record{Response: value})
+			}
+		}
+		if storeErr != nil {
+			logger.Ctx(ctx).Error("Can not store idempotent response", zap.String("key", key), zap.Error(storeErr))
+		}
+		completed = storeErr == nil
+		return resp, err
+	}
+}
+
+func cachedResponse(record *Record) (interface{}, error) {
+	if record.State != StateCompleted {
+		return nil, status.Error(codes.Aborted, "request with the same idempotency-key is in progress")
+	}
+	anyResp := &anypb.Any{}
+	if err := proto.Unmarshal(record.Response, anyResp); err != nil {
+		return nil, status.Errorf(codes.Internal, "can not read cached response: %v", err)
+	}
+	resp, err := anyResp.UnmarshalNew()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "can not read cached response: %v", err)
+	}
+	return resp, nil
+}
+
+func releaseKey(ctx context.Context, store *Store, key, token string) {
+	if err := store.Release(ctx, key, token); err != nil {
+		logger.Ctx(ctx).Error("Can not release idempotency key", zap.String("key", key), zap.Error(err))
+	}
+}
@@ -0,0 +1,71 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	store, _ := newTestStore(t)
+	interceptor := NewUnaryServerInterceptor(store)
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Create"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "k1"))
+
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return wrapperspb.String("created"), nil
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := interceptor(ctx, nil, info, handler)
+		if err != nil || !proto.Equal(resp.(proto.Message), wrapperspb.String("created")) {
+			t.Fatalf("call %d = %v, %v", i, resp, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+
+	failed := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Internal, "failed")
+	}
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "k2"))
+	if _, err := interceptor(ctx, nil, info, failed); status.Code(err) != codes.Internal {
+		t.Fatalf("err = %v, want Internal", err)
+	}
+	if _, err := interceptor(ctx, nil, info, handler); err != nil {
+		t.Errorf("retry after an error = %v, want the key released", err)
+	}
+}
+
+func TestUnaryServerInterceptorPanic(t *testing.T) {
+	store, _ := newTestStore(t)
+	interceptor := NewUnaryServerInterceptor(store)
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Create"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "k"))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("the panic should be propagated")
+			}
+		}()
+		_, _ = interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("boom")
+		})
+	}()
+
+	resp, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return wrapperspb.String("retried"), nil
+	})
+	if err != nil || !proto.Equal(resp.(proto.Message), wrapperspb.String("retried")) {
+		t.Errorf("retry after a panic = %v, %v, want the key released", resp, err)
+	}
+}
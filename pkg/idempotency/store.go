@@ -0,0 +1,160 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	redisLib "github.com/go-redis/redis/v8"
+	"github.com/nmtri1912/go-common/pkg/redis"
+)
+
+const (
+	StateInProgress = "IN_PROGRESS"
+	StateCompleted  = "COMPLETED"
+)
+
+const keyPrefix = "idempotency:"
+
+// storeTimeout bounds Complete and Release, which run on a context detached from the request:
+// after the request deadline, the key must still be completed or released
+const storeTimeout = 3 * time.Second
+
+// ErrLockLost is returned by Complete when key expired and was taken by another request
+var ErrLockLost = errors.New("idempotency key is owned by another request")
+
+// completeScript replaces the in-progress record of KEYS[1] only if it's still the one of the caller (ARGV[1])
+var completeScript = redisLib.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return false`)
+
+// releaseScript deletes KEYS[1] only if it's still the in-progress record of the caller (ARGV[1])
+var releaseScript = redisLib.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Record is the state of an idempotency key stored in Redis
+type Record struct {
+	State string `json:"state"`
+	// Token identifies the owner of an in-progress record
+	Token       string `json:"token,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Response    []byte `json:"response,omitempty"`
+}
+
+// Store keeps idempotency records in Redis. An in-progress record expires after lockTTL,
+// so that a crashed request doesn't block its retries forever. A completed record expires after ttl
+type Store struct {
+	cache   redis.Cache
+	lockTTL time.Duration
+	ttl     time.Duration
+}
+
+func NewStore(cache redis.Cache, lockTTL, ttl time.Duration) *Store {
+	return &Store{
+		cache:   cache,
+		lockTTL: lockTTL,
+		ttl:     ttl,
+	}
+}
+
+// Begin marks key as in progress. It returns a token if the caller owns the key and must process the request,
+// then complete or release it with this token. Otherwise, the token is empty and the existing record is returned
+func (s *Store) Begin(ctx context.Context, key string) (string, *Record, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", nil, err
+	}
+	inProgress, err := inProgressValue(token)
+	if err != nil {
+		return "", nil, err
+	}
+	acquired, err := s.cache.SetNX(ctx, keyPrefix+key, inProgress, s.lockTTL).Result()
+	if err != nil {
+		return "", nil, err
+	}
+	if acquired {
+		return token, nil, nil
+	}
+
+	value, err := s.cache.Get(ctx, keyPrefix+key).Bytes()
+	if err == redisLib.Nil {
+		// expired between SETNX and GET, let the client retry
+		return "", &Record{State: StateInProgress}, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	record := &Record{}
+	if err := json.Unmarshal(value, record); err != nil {
+		return "", nil, err
+	}
+	return "", record, nil
+}
+
+// Complete stores the response of key, which is returned to the retries until ttl expires.
+// It returns ErrLockLost when key is no more owned by token, the record of the new owner is kept
+func (s *Store) Complete(ctx context.Context, key, token string, record *Record) error {
+	inProgress, err := inProgressValue(token)
+	if err != nil {
+		return err
+	}
+	record.State = StateCompleted
+	record.Token = ""
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := detachedContext(ctx)
+	defer cancel()
+	err = completeScript.Run(ctx, s.cache, []string{keyPrefix + key}, inProgress, value, s.ttl.Milliseconds()).Err()
+	if err == redisLib.Nil {
+		return ErrLockLost
+	}
+	return err
+}
+
+// Release removes key if it's still owned by token, so that the request can be retried, e.g. after a failure
+func (s *Store) Release(ctx context.Context, key, token string) error {
+	inProgress, err := inProgressValue(token)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := detachedContext(ctx)
+	defer cancel()
+	return releaseScript.Run(ctx, s.cache, []string{keyPrefix + key}, inProgress).Err()
+}
+
+func inProgressValue(token string) ([]byte, error) {
+	return json.Marshal(&Record{State: StateInProgress, Token: token})
+}
+
+func newToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// detachedContext keeps the values of ctx (e.g. the trace) but not its deadline,
+// which may be exceeded when the handler returns
+func detachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(detached{ctx}, storeTimeout)
+}
+
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redisLib "github.com/go-redis/redis/v8"
+	"github.com/nmtri1912/go-common/pkg/redis"
+)
+
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redisLib.NewClient(&redisLib.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewStore(&redis.RedisCache{UniversalClient: client}, time.Minute, time.Hour), server
+}
+
+func TestStoreBeginComplete(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	token, _, err := store.Begin(ctx, "k")
+	if err != nil || len(token) == 0 {
+		t.Fatalf("Begin = %q, %v, want a token", token, err)
+	}
+	duplicate, record, err := store.Begin(ctx, "k")
+	if err != nil || len(duplicate) > 0 || record.State != StateInProgress {
+		t.Fatalf("concurrent Begin = %q, %+v, %v, want the in-progress record", duplicate, record, err)
+	}
+
+	if err := store.Complete(ctx, "k", token, &Record{StatusCode: 201, Response: []byte("done")}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	_, record, err = store.Begin(ctx, "k")
+	if err != nil || record.State != StateCompleted || string(record.Response) != "done" || len(record.Token) > 0 {
+		t.Fatalf("retry Begin = %+v, %v, want the completed record", record, err)
+	}
+}
+
+func TestStoreLockTakenOver(t *testing.T) {
+	store, server := newTestStore(t)
+	ctx := context.Background()
+
+	first, _, _ := store.Begin(ctx, "k")
+	server.FastForward(time.Minute)
+	second, _, err := store.Begin(ctx, "k")
+	if err != nil || len(second) == 0 {
+		t.Fatalf("Begin after lockTTL = %q, %v, want a token", second, err)
+	}
+
+	if err := store.Complete(ctx, "k", first, &Record{Response: []byte("first")}); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Complete of the expired owner = %v, want ErrLockLost", err)
+	}
+	if err := store.Release(ctx, "k", first); err != nil {
+		t.Errorf("Release of the expired owner: %v", err)
+	}
+	_, record, _ := store.Begin(ctx, "k")
+	if record == nil || record.State != StateInProgress || record.Token != second {
+		t.Fatalf("record = %+v, want the in-progress record of the second owner", record)
+	}
+
+	if err := store.Complete(ctx, "k", second, &Record{Response: []byte("second")}); err != nil {
+		t.Fatalf("Complete of the owner: %v", err)
+	}
+	_, record, _ = store.Begin(ctx, "k")
+	if string(record.Response) != "second" {
+		t.Errorf("response = %q, want second", record.Response)
+	}
+}
+
+func TestStoreCompleteAfterDeadline(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	token, _, err := store.Begin(ctx, "k")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	<-ctx.Done()
+	if err := store.Complete(ctx, "k", token, &Record{Response: []byte("done")}); err != nil {
+		t.Errorf("Complete after the request deadline: %v", err)
+	}
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), time.Second)
+	defer releaseCancel()
+	token, _, _ = store.Begin(releaseCtx, "released")
+	releaseCancel()
+	if err := store.Release(releaseCtx, "released", token); err != nil {
+		t.Errorf("Release after the request deadline: %v", err)
+	}
+	if token, _, _ := store.Begin(context.Background(), "released"); len(token) == 0 {
+		t.Error("key should be released")
+	}
+}
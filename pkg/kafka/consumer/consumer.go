@@ -6,13 +6,19 @@ import (
 	"time"
 
 	"github.com/Shopify/sarama"
+	grpc_util "github.com/nmtri1912/go-common/pkg/grpc"
 	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/nmtri1912/go-common/pkg/propagation"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/Shopify/sarama/otelsarama"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// kafkaLogger logs every message at info, turn it down with logger.levels, e.g. [kafka.consumer=warn]
+var kafkaLogger = logger.Named("kafka.consumer")
+
 type KafkaConsumer struct {
 	Brokers     []string
 	GroupId     string
@@ -30,7 +36,7 @@ type KafkaConsumer struct {
 
 func NewKafkaConsumer(brokers []string, groupId string, topics []string, handler func(message *sarama.ConsumerMessage), numWorker int) *KafkaConsumer {
 	if numWorker < 1 {
-		logger.L().Fatal("Number of workers invalid")
+		kafkaLogger.Fatal("Number of workers invalid")
 	}
 
 	return &KafkaConsumer{
@@ -49,7 +55,7 @@ func NewKafkaConsumer(brokers []string, groupId string, topics []string, handler
 }
 
 func (c *KafkaConsumer) Start() {
-	logger.L().Info("Starting Sarama consumer")
+	kafkaLogger.Info("Starting Sarama consumer")
 
 	// https://github.com/Shopify/sarama/blob/main/examples/consumergroup/main.go#L68
 	config := sarama.NewConfig()
@@ -60,10 +66,10 @@ func (c *KafkaConsumer) Start() {
 	var err error
 	c.client, err = sarama.NewConsumerGroup(c.Brokers, c.GroupId, config)
 	if err != nil {
-		logger.L().Fatal("Error creating consumer group client", zap.Error(err))
+		kafkaLogger.Fatal("Error creating consumer group client", zap.Error(err))
 	}
 
-	logger.L().Info("Start worker", zap.Int("numbers", c.numWorker))
+	kafkaLogger.Info("Start worker", zap.Int("numbers", c.numWorker))
 	for i := 0; i < c.numWorker; i++ {
 		c.waitWorker.Add(1)
 		go func() {
@@ -86,13 +92,13 @@ func (c *KafkaConsumer) Start() {
 		}()
 	}
 
-	logger.L().Info("Consumer start consume")
+	kafkaLogger.Info("Consumer start consume")
 	c.waitConsume.Add(1)
 	go func() {
 		defer c.waitConsume.Done()
 		for {
 			if err := c.client.Consume(ctx, c.Topics, c); err != nil {
-				logger.L().Error("Error from consumer", zap.Error(err))
+				kafkaLogger.Error("Error from consumer", zap.Error(err))
 			}
 			// check if context was cancelled, signaling that the consumer should stop
 			if ctx.Err() != nil {
@@ -112,16 +118,16 @@ func (c *KafkaConsumer) Start() {
 }
 
 func (c *KafkaConsumer) Close() {
-	logger.L().Info("Stopping consumer")
+	kafkaLogger.Info("Stopping consumer")
 	close(c.quitConsume)
 	c.waitConsume.Wait()
 	if err := c.client.Close(); err != nil {
-		logger.L().Error("Error closing client", zap.Error(err))
+		kafkaLogger.Error("Error closing client", zap.Error(err))
 	}
 	close(c.quitWorker)
 	c.waitWorker.Wait()
 	close(c.messages)
-	logger.L().Info("All workers have exited")
+	kafkaLogger.Info("All workers have exited")
 }
 
 // Setup is run at the beginning of a new session, before ConsumeClaim
@@ -141,18 +147,20 @@ func (c *KafkaConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim
 	for {
 		select {
 		case message := <-claim.Messages():
-			wMsg := otelsarama.NewConsumerMessageCarrier(message)
-			nCtx := otel.GetTextMapPropagator().Extract(context.Background(), wMsg)
-			spanCtx := trace.SpanContextFromContext(nCtx)
-
-			logger.L().Info("Receive kafka message",
-				zap.String("topic", message.Topic),
-				zap.Int32("partition", message.Partition),
-				zap.Int64("offset", message.Offset),
-				zap.String("key", string(message.Key)),
-				zap.String("value", string(message.Value)),
-				zap.String("trace_id", string(spanCtx.TraceID().String())),
-			)
+			if kafkaLogger.Enabled(zapcore.InfoLevel) {
+				wMsg := otelsarama.NewConsumerMessageCarrier(message)
+				nCtx := otel.GetTextMapPropagator().Extract(context.Background(), wMsg)
+				spanCtx := trace.SpanContextFromContext(nCtx)
+
+				kafkaLogger.Info("Receive kafka message",
+					zap.String("topic", message.Topic),
+					zap.Int32("partition", message.Partition),
+					zap.Int64("offset", message.Offset),
+					zap.String("key", string(message.Key)),
+					zap.String("value", string(message.Value)),
+					zap.String("trace_id", string(spanCtx.TraceID().String())),
+				)
+			}
 			c.messages <- message
 			session.MarkMessage(message, "")
 		case <-session.Context().Done():
@@ -165,5 +173,23 @@ func ExtractSpanAndTracingContext(message *sarama.ConsumerMessage) (context.Cont
 	wMsg := otelsarama.NewConsumerMessageCarrier(message)
 	nCtx := otel.GetTextMapPropagator().Extract(context.Background(), wMsg)
 	nCtx, span := otel.Tracer("kafka:"+message.Topic).Start(nCtx, "consume:kafka:"+message.Topic)
+	nCtx = propagation.ExtractKafkaHeaders(nCtx, message, propagation.Keys())
+	nCtx = logger.WithFields(nCtx, messageFields(nCtx, message)...)
 	return nCtx, span
 }
+
+// messageFields returns the log fields of message, including the standard fields forwarded in its headers
+func messageFields(ctx context.Context, message *sarama.ConsumerMessage) []zap.Field {
+	fields := []zap.Field{
+		zap.String("topic", message.Topic),
+		zap.Int32("partition", message.Partition),
+		zap.Int64("offset", message.Offset),
+	}
+	if requestId := propagation.Get(ctx, grpc_util.RequestIdMetadataKey); len(requestId) > 0 {
+		fields = append(fields, zap.String(logger.FieldRequestId, requestId))
+	}
+	if userId := propagation.Get(ctx, grpc_util.UserIdMetadataKey); len(userId) > 0 {
+		fields = append(fields, zap.String(logger.FieldUserId, userId))
+	}
+	return fields
+}
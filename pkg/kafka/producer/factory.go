@@ -3,11 +3,13 @@ package producer
 import (
 	"context"
 
+	"github.com/nmtri1912/go-common/pkg/health"
 	"go.uber.org/fx"
 )
 
 func NewKafkaTemplate(lifecycle fx.Lifecycle, brokers []string) KafkaProducer {
 	p := NewKafkaProducer(brokers)
+	health.Register("kafka", health.KafkaBrokersCheck(brokers))
 	lifecycle.Append(fx.Hook{
 		OnStop: func(ctx context.Context) error {
 			p.Close()
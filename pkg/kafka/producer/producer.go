@@ -6,12 +6,17 @@ import (
 
 	"github.com/Shopify/sarama"
 	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/nmtri1912/go-common/pkg/propagation"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/Shopify/sarama/otelsarama"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// kafkaLogger logs every message at info, turn it down with logger.levels, e.g. [kafka.producer=warn]
+var kafkaLogger = logger.Named("kafka.producer")
+
 type KafkaProducer interface {
 	Close()
 	Produce(topic string, key string, value []byte)
@@ -22,7 +27,44 @@ type kafkaProducerImpl struct {
 	producer sarama.AsyncProducer
 }
 
-func NewKafkaProducer(brokers []string) KafkaProducer {
+type options struct {
+	resultLogging bool
+	errorHandler  func(err *sarama.ProducerError)
+}
+
+type Option func(o *options)
+
+// WithoutResultLogging disables the log of every pushed and failed message,
+// required when the producer is used to ship logs
+func WithoutResultLogging() Option {
+	return func(o *options) {
+		o.resultLogging = false
+	}
+}
+
+// WithErrorHandler is called for every message failed to be pushed
+func WithErrorHandler(handler func(err *sarama.ProducerError)) Option {
+	return func(o *options) {
+		o.errorHandler = handler
+	}
+}
+
+// NewKafkaProducer is NewProducer, the process exits when the producer can't be created
+func NewKafkaProducer(brokers []string, opts ...Option) KafkaProducer {
+	p, err := NewProducer(brokers, opts...)
+	if err != nil {
+		kafkaLogger.Fatal("Error creating kafka producer", zap.Error(err))
+	}
+	return p
+}
+
+// NewProducer creates a producer to brokers, it returns the error when it can't be created, e.g. brokers are unreachable
+func NewProducer(brokers []string, opts ...Option) (KafkaProducer, error) {
+	o := &options{resultLogging: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	// https://github.com/Shopify/sarama/blob/main/examples/http_server/http_server.go#L219
 	config := sarama.NewConfig()
 	config.Version = sarama.V2_3_0_0
@@ -35,21 +77,27 @@ func NewKafkaProducer(brokers []string) KafkaProducer {
 
 	producer, err := sarama.NewAsyncProducer(brokers, config)
 	if err != nil {
-		logger.L().Fatal("Error creating kafka producer", zap.Error(err))
+		return nil, err
 	}
 
-	logResultMessage(producer)
+	logResultMessage(producer, o)
 
-	return &kafkaProducerImpl{producer: producer}
+	return &kafkaProducerImpl{producer: producer}, nil
 }
 
-func logResultMessage(producer sarama.AsyncProducer) {
+func logResultMessage(producer sarama.AsyncProducer, o *options) {
 	// log error message
 	go func() {
 		for err := range producer.Errors() {
+			if o.errorHandler != nil {
+				o.errorHandler(err)
+			}
+			if !o.resultLogging {
+				continue
+			}
 			key, _ := err.Msg.Key.Encode()
 			value, _ := err.Msg.Value.Encode()
-			logger.L().Info("Failed to push kafka message",
+			kafkaLogger.Info("Failed to push kafka message",
 				zap.String("topic", err.Msg.Topic),
 				zap.Int32("partition", err.Msg.Partition),
 				zap.Int64("offset", err.Msg.Offset),
@@ -63,13 +111,16 @@ func logResultMessage(producer sarama.AsyncProducer) {
 	// log success message
 	go func() {
 		for result := range producer.Successes() {
+			if !o.resultLogging || !kafkaLogger.Enabled(zapcore.InfoLevel) {
+				continue
+			}
 			wResult := otelsarama.NewProducerMessageCarrier(result)
 			ctx := otel.GetTextMapPropagator().Extract(context.Background(), wResult)
 			spanCtx := trace.SpanContextFromContext(ctx)
 
 			key, _ := result.Key.Encode()
 			value, _ := result.Value.Encode()
-			logger.L().Info("Push kafka message successfully",
+			kafkaLogger.Info("Push kafka message successfully",
 				zap.String("topic", result.Topic),
 				zap.Int32("partition", result.Partition),
 				zap.Int64("offset", result.Offset),
@@ -83,9 +134,9 @@ func logResultMessage(producer sarama.AsyncProducer) {
 
 // Close https://github.com/Shopify/sarama/blob/main/examples/http_server/http_server.go#L91
 func (p *kafkaProducerImpl) Close() {
-	logger.L().Info("Closing kafka producer")
+	kafkaLogger.Info("Closing kafka producer")
 	if err := p.producer.Close(); err != nil {
-		logger.L().Error("Failed to shut down access log producer cleanly", zap.Error(err))
+		kafkaLogger.Error("Failed to shut down access log producer cleanly", zap.Error(err))
 	}
 }
 
@@ -111,6 +162,7 @@ func (p *kafkaProducerImpl) ProduceCtx(ctx context.Context, topic string, key st
 	nCtx, span := otel.Tracer("kafka:"+topic).Start(ctx, "produce:kafka:"+topic)
 	defer span.End()
 	otel.GetTextMapPropagator().Inject(nCtx, wrapperMsg)
+	propagation.InjectKafkaHeaders(ctx, msg)
 
 	p.producer.Input() <- msg
 }
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+)
+
+// LoadLevels applies logger.level (global logger) and logger.levels (entries `name=level`) from the configuration.
+// Named loggers removed from logger.levels are reset to their parent level
+func LoadLevels() error {
+	if viper.IsSet("logger.level") {
+		level, err := zapcore.ParseLevel(viper.GetString("logger.level"))
+		if err != nil {
+			return err
+		}
+		levels.configure(RootName, level)
+	}
+
+	named := map[string]zapcore.Level{}
+	for _, entry := range viper.GetStringSlice("logger.levels") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid logger level %q, expected name=level", entry)
+		}
+		level, err := zapcore.ParseLevel(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return err
+		}
+		named[strings.TrimSpace(parts[0])] = level
+	}
+	for _, name := range levels.configuredNames() {
+		if _, exist := named[name]; !exist && name != RootName {
+			levels.unconfigure(name)
+		}
+	}
+	for name, level := range named {
+		levels.configure(name, level)
+	}
+	return nil
+}
+
+// LevelTTL returns logger.level-ttl-sec, the default time before a level changed through LevelHandler is reverted.
+// 0 means never
+func LevelTTL() time.Duration {
+	return time.Duration(viper.GetInt("logger.level-ttl-sec")) * time.Second
+}
+
+type samplingConfig struct {
+	enabled    bool
+	tick       time.Duration
+	initial    int
+	thereafter int
+}
+
+// getSamplingConfig returns logger.sampling.*, enabled by default in production like zap.NewProductionConfig
+func getSamplingConfig(production bool) samplingConfig {
+	enabled := production
+	if viper.IsSet("logger.sampling.enabled") {
+		enabled = viper.GetBool("logger.sampling.enabled")
+	}
+	return samplingConfig{
+		enabled:    enabled,
+		tick:       time.Duration(getIntOrDefault("logger.sampling.tick-ms", 1000)) * time.Millisecond,
+		initial:    getIntOrDefault("logger.sampling.initial", 100),
+		thereafter: getIntOrDefault("logger.sampling.thereafter", 100),
+	}
+}
+
+type rateLimitConfig struct {
+	limit    int
+	interval time.Duration
+}
+
+// getRateLimitConfig returns logger.rate-limit.*, disabled when per-call-site is 0
+func getRateLimitConfig() rateLimitConfig {
+	return rateLimitConfig{
+		limit:    viper.GetInt("logger.rate-limit.per-call-site"),
+		interval: time.Duration(getIntOrDefault("logger.rate-limit.interval-ms", 1000)) * time.Millisecond,
+	}
+}
+
+func getIntOrDefault(key string, defaultValue int) int {
+	if viper.IsSet(key) {
+		return viper.GetInt(key)
+	}
+	return defaultValue
+}
+
+// getRedactor returns the redactor of logger.redaction.*, nil when disabled
+func getRedactor() (*redactor, error) {
+	if viper.IsSet("logger.redaction.enabled") && !viper.GetBool("logger.redaction.enabled") {
+		return nil, nil
+	}
+	fields := DefaultRedactedFields
+	if viper.IsSet("logger.redaction.fields") {
+		fields = viper.GetStringSlice("logger.redaction.fields")
+	}
+	patterns := DefaultRedactionPatterns
+	if viper.IsSet("logger.redaction.patterns") {
+		patterns = viper.GetStringSlice("logger.redaction.patterns")
+	}
+	return newRedactor(fields, patterns, viper.GetStringSlice("logger.redaction.custom-patterns"))
+}
@@ -1,34 +1,144 @@
 package logger
 
 import (
+	"sync/atomic"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// zapLogger resolves its zap.Logger lazily, so that L() and Named() follow InitLogger and Replace,
+// and the fields of Ctx() and With() are only encoded once the logger is used
 type zapLogger struct {
+	name string
+	get  func() *zap.Logger
+}
+
+func newRootLogger() *zapLogger {
+	return &zapLogger{get: rootLogger}
+}
+
+// newNamedLogger follows the global logger, the child is rebuilt when the global logger is replaced
+func newNamedLogger(name string) *zapLogger {
+	return &zapLogger{name: name, get: cachedLogger(rootLogger, func(base *zap.Logger) *zap.Logger {
+		return base.Named(name).WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newLevelCore(core, name)
+		}))
+	})}
+}
+
+// derive returns a child logger built by build on first use, and rebuilt when the parent changes
+func (l *zapLogger) derive(name string, build func(parent *zap.Logger) *zap.Logger) *zapLogger {
+	return &zapLogger{name: name, get: cachedLogger(l.get, build)}
+}
+
+// cachedLogger returns build(parent()), rebuilt only when parent() changes, e.g. after InitLogger or Replace
+func cachedLogger(parent func() *zap.Logger, build func(parent *zap.Logger) *zap.Logger) func() *zap.Logger {
+	var cache atomic.Value // derivedCache
+	return func() *zap.Logger {
+		base := parent()
+		if cached, ok := cache.Load().(derivedCache); ok && cached.base == base {
+			return cached.logger
+		}
+		logger := build(base)
+		cache.Store(derivedCache{base: base, logger: logger})
+		return logger
+	}
+}
+
+type derivedCache struct {
+	base   *zap.Logger
 	logger *zap.Logger
 }
 
+func (l *zapLogger) With(fields ...zapcore.Field) ILogger {
+	if len(fields) == 0 {
+		return l
+	}
+	return l.derive(l.name, func(parent *zap.Logger) *zap.Logger {
+		return parent.With(fields...)
+	})
+}
+
+func (l *zapLogger) Named(name string) ILogger {
+	if l == globalLogger {
+		return newNamedLogger(name)
+	}
+	fullName := name
+	if len(l.name) > 0 {
+		fullName = l.name + "." + name
+	}
+	return l.derive(fullName, func(parent *zap.Logger) *zap.Logger {
+		return parent.Named(name).WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newLevelCore(core, fullName)
+		}))
+	})
+}
+
+func (l *zapLogger) Enabled(level zapcore.Level) bool {
+	return l.get().Core().Enabled(level)
+}
+
 func (l *zapLogger) Debug(msg string, fields ...zapcore.Field) {
-	l.logger.Debug(msg, fields...)
+	l.get().Debug(msg, fields...)
 }
 
 func (l *zapLogger) Info(msg string, fields ...zapcore.Field) {
-	l.logger.Info(msg, fields...)
+	l.get().Info(msg, fields...)
 }
 
 func (l *zapLogger) Warn(msg string, fields ...zapcore.Field) {
-	l.logger.Warn(msg, fields...)
+	l.get().Warn(msg, fields...)
 }
 
 func (l *zapLogger) Error(msg string, fields ...zapcore.Field) {
-	l.logger.Error(msg, fields...)
+	l.get().Error(msg, fields...)
 }
 
 func (l *zapLogger) Fatal(msg string, fields ...zapcore.Field) {
-	l.logger.Fatal(msg, fields...)
+	l.get().Fatal(msg, fields...)
 }
 
 func (l *zapLogger) Panic(msg string, fields ...zapcore.Field) {
-	l.logger.Panic(msg, fields...)
+	l.get().Panic(msg, fields...)
+}
+
+func (l *zapLogger) Debugf(template string, args ...interface{}) {
+	l.get().Sugar().Debugf(template, args...)
+}
+
+func (l *zapLogger) Infof(template string, args ...interface{}) {
+	l.get().Sugar().Infof(template, args...)
+}
+
+func (l *zapLogger) Warnf(template string, args ...interface{}) {
+	l.get().Sugar().Warnf(template, args...)
+}
+
+func (l *zapLogger) Errorf(template string, args ...interface{}) {
+	l.get().Sugar().Errorf(template, args...)
+}
+
+func (l *zapLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.get().Sugar().Debugw(msg, keysAndValues...)
+}
+
+func (l *zapLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.get().Sugar().Infow(msg, keysAndValues...)
+}
+
+func (l *zapLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.get().Sugar().Warnw(msg, keysAndValues...)
+}
+
+func (l *zapLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.get().Sugar().Errorw(msg, keysAndValues...)
+}
+
+// zapOf returns the zap.Logger behind l, the global one for other implementations
+func zapOf(l ILogger) (*zap.Logger, string) {
+	if logger, ok := l.(*zapLogger); ok {
+		return logger.get(), logger.name
+	}
+	return rootLogger(), RootName
 }
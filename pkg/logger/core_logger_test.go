@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDerivedLoggersFollowReplace(t *testing.T) {
+	child := L().With(zap.String("k", "v"))
+	named := Named("derived").Named("child")
+	// cache the loggers built from the current root
+	child.Debug("before")
+	named.Debug("before")
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	restore := Replace(zap.New(core))
+	defer restore()
+
+	child.Info("child")
+	named.Info("named")
+	if entries := logs.FilterMessage("child").All(); len(entries) != 1 || entries[0].ContextMap()["k"] != "v" {
+		t.Errorf("child entries = %v, want one entry with k=v on the replaced logger", entries)
+	}
+	if entries := logs.FilterMessage("named").All(); len(entries) != 1 || entries[0].LoggerName != "derived.child" {
+		t.Errorf("named entries = %v, want one entry of derived.child on the replaced logger", entries)
+	}
+
+	restore()
+	child.Info("restored")
+	if logs.FilterMessage("restored").Len() != 0 {
+		t.Error("child should follow the restored logger")
+	}
+}
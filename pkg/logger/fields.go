@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Standard request-scoped fields, populated by the gRPC interceptors and the Kafka consumer
+const (
+	FieldClientId  = "client_id"
+	FieldUserId    = "user_id"
+	FieldRequestId = "request_id"
+)
+
+// Fields of the recovered panics, logged by the gRPC recover interceptor and the gin recovery middleware
+const (
+	FieldPanic = "panic"
+	FieldStack = "stack"
+)
+
+type fieldsKey struct{}
+
+// baggageKeys are the OpenTelemetry baggage entries logged by Ctx, set by InitLogger from logger.baggage-keys
+var baggageKeys []string
+
+// WithFields stores fields in ctx, they are added to every log written with Ctx(ctx).
+// A field replaces the field with the same key already stored
+func WithFields(ctx context.Context, fields ...zapcore.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	existing := FieldsFromContext(ctx)
+	merged := make([]zapcore.Field, 0, len(existing)+len(fields))
+	for _, field := range existing {
+		if !containsKey(fields, field.Key) {
+			merged = append(merged, field)
+		}
+	}
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFromContext returns the fields stored by WithFields, it must not be modified
+func FieldsFromContext(ctx context.Context) []zapcore.Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]zapcore.Field)
+	return fields
+}
+
+// contextFields returns the stored fields and the configured baggage entries present in ctx
+func contextFields(ctx context.Context) []zapcore.Field {
+	fields := FieldsFromContext(ctx)
+	if len(baggageKeys) == 0 {
+		return fields
+	}
+	bag := baggage.FromContext(ctx)
+	var baggageFields []zapcore.Field
+	for _, key := range baggageKeys {
+		if member := bag.Member(key); len(member.Key()) > 0 && !containsKey(fields, key) {
+			baggageFields = append(baggageFields, zap.String(key, member.Value()))
+		}
+	}
+	if len(baggageFields) == 0 {
+		return fields
+	}
+	return append(baggageFields, fields...)
+}
+
+func containsKey(fields []zapcore.Field, key string) bool {
+	for _, field := range fields {
+		if field.Key == key {
+			return true
+		}
+	}
+	return false
+}
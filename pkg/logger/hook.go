@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorHook receives the Error, DPanic, Panic and Fatal entries, after redaction.
+// fields include the fields added with With and Ctx
+type ErrorHook func(entry zapcore.Entry, fields []zapcore.Field)
+
+var (
+	errorHooksMutex sync.Mutex
+	errorHooks      atomic.Value // []*ErrorHook
+)
+
+// AddErrorHook registers hook on every logger, the returned function removes it
+func AddErrorHook(hook ErrorHook) func() {
+	errorHooksMutex.Lock()
+	defer errorHooksMutex.Unlock()
+	registered := &hook
+	errorHooks.Store(append(loadErrorHooks(), registered))
+	return func() {
+		errorHooksMutex.Lock()
+		defer errorHooksMutex.Unlock()
+		hooks := loadErrorHooks()
+		remaining := make([]*ErrorHook, 0, len(hooks))
+		for _, h := range hooks {
+			if h != registered {
+				remaining = append(remaining, h)
+			}
+		}
+		errorHooks.Store(remaining)
+	}
+}
+
+func loadErrorHooks() []*ErrorHook {
+	hooks, _ := errorHooks.Load().([]*ErrorHook)
+	return hooks
+}
+
+// hookCore calls the error hooks in addition to writing to the wrapped core
+type hookCore struct {
+	zapcore.Core
+	fields []zapcore.Field
+}
+
+func newHookCore(core zapcore.Core) zapcore.Core {
+	return &hookCore{Core: core}
+}
+
+func (c *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	return &hookCore{Core: c.Core.With(fields), fields: append(merged, fields...)}
+}
+
+func (c *hookCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked = c.Core.Check(entry, checked)
+	if entry.Level >= zapcore.ErrorLevel && len(loadErrorHooks()) > 0 {
+		checked = checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write only calls the hooks, the wrapped core is added to the checked entry by Check
+func (c *hookCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if len(c.fields) > 0 {
+		fields = append(append(make([]zapcore.Field, 0, len(c.fields)+len(fields)), c.fields...), fields...)
+	}
+	for _, hook := range loadErrorHooks() {
+		(*hook)(entry, fields)
+	}
+	return nil
+}
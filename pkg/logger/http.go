@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type levelRequest struct {
+	Name   string `json:"name"`
+	Level  string `json:"level"`
+	TTLSec *int   `json:"ttl_sec"`
+}
+
+// LevelHandler serves the levels of the loggers:
+//   - GET returns the level of the global logger ("") and of every overridden named logger
+//   - PUT with {"name": "kafka", "level": "debug", "ttl_sec": 300} changes a level, the global logger when name is empty.
+//     The configured level is restored after ttl_sec, defaultTTL if not set
+//   - DELETE ?name=kafka restores the configured level
+func LevelHandler(defaultTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var request levelRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			level, err := zapcore.ParseLevel(request.Level)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			ttl := defaultTTL
+			if request.TTLSec != nil {
+				ttl = time.Duration(*request.TTLSec) * time.Second
+			}
+			SetLevel(request.Name, level, ttl)
+			Ctx(r.Context()).Warn("Log level changed",
+				zap.String("name", request.Name),
+				zap.Stringer("level", level),
+				zap.Duration("ttl", ttl),
+			)
+		case http.MethodDelete:
+			ResetLevel(r.URL.Query().Get("name"))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body := map[string]string{}
+		for name, level := range Levels() {
+			body[name] = level.String()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func serveLevel(t *testing.T, handler http.Handler, method, target, body string) (int, map[string]string) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+	var levels map[string]string
+	if recorder.Code == http.StatusOK {
+		if err := json.NewDecoder(recorder.Body).Decode(&levels); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return recorder.Code, levels
+}
+
+func TestLevelHandler(t *testing.T) {
+	isolateLevels(t)
+	logs := NewTestLogger(t)
+	handler := LevelHandler(time.Hour)
+
+	code, got := serveLevel(t, handler, http.MethodPut, "/log/level", `{"name": "kafka", "level": "debug"}`)
+	if code != http.StatusOK || got["kafka"] != "debug" || got[""] != "info" {
+		t.Errorf("PUT = %d, %v", code, got)
+	}
+	logs.AssertLogged(t, zapcore.WarnLevel, "Log level changed", map[string]interface{}{"name": "kafka", "level": "debug"})
+
+	if code, got := serveLevel(t, handler, http.MethodGet, "/log/level", ""); code != http.StatusOK || got["kafka"] != "debug" {
+		t.Errorf("GET = %d, %v", code, got)
+	}
+	if code, got := serveLevel(t, handler, http.MethodDelete, "/log/level?name=kafka", ""); code != http.StatusOK || len(got["kafka"]) > 0 {
+		t.Errorf("DELETE = %d, %v", code, got)
+	}
+
+	for body, want := range map[string]int{
+		`{"name": "kafka", "level": "verbose"}`: http.StatusBadRequest,
+		`{"name": `:                             http.StatusBadRequest,
+	} {
+		if code, _ := serveLevel(t, handler, http.MethodPut, "/log/level", body); code != want {
+			t.Errorf("PUT %s = %d, want %d", body, code, want)
+		}
+	}
+	if code, _ := serveLevel(t, handler, http.MethodPatch, "/log/level", ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("PATCH = %d, want %d", code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestLevelHandlerTTL(t *testing.T) {
+	isolateLevels(t)
+	NewTestLogger(t)
+	handler := LevelHandler(50 * time.Millisecond)
+
+	// the default ttl
+	serveLevel(t, handler, http.MethodPut, "/log/level", `{"level": "debug"}`)
+	// ttl_sec 0 never reverts
+	serveLevel(t, handler, http.MethodPut, "/log/level", `{"name": "kafka", "level": "error", "ttl_sec": 0}`)
+	if got := Levels()[RootName]; got != zapcore.DebugLevel {
+		t.Fatalf("global level = %v, want debug", got)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for Levels()[RootName] != zapcore.InfoLevel {
+		if time.Now().After(deadline) {
+			t.Fatal("the global level is not reverted after the default ttl")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := Levels()["kafka"]; got != zapcore.ErrorLevel {
+		t.Errorf("kafka level = %v, want error without ttl", got)
+	}
+}
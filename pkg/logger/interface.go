@@ -10,4 +10,23 @@ type ILogger interface {
 	Error(msg string, fields ...zapcore.Field)
 	Fatal(msg string, fields ...zapcore.Field)
 	Panic(msg string, fields ...zapcore.Field)
+
+	// printf-style
+	Debugf(template string, args ...interface{})
+	Infof(template string, args ...interface{})
+	Warnf(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+
+	// loosely typed key-value pairs, e.g. Infow("Request", "method", method, "duration", duration)
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+
+	// With returns a child logger adding fields to every log
+	With(fields ...zapcore.Field) ILogger
+	// Named returns a child logger whose level can be changed with SetLevel(<parent name>.<name>, ...)
+	Named(name string) ILogger
+	// Enabled reports whether logs at level are written, e.g. to skip building expensive fields
+	Enabled(level zapcore.Level) bool
 }
@@ -0,0 +1,148 @@
+package kafkasink
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/nmtri1912/go-common/pkg/kafka/producer"
+	"github.com/nmtri1912/go-common/pkg/logger"
+)
+
+// SinkKafka is the type of kafka sinks in logger.sinks, available once this package is imported
+const SinkKafka = "kafka"
+
+const (
+	defaultBufferSize   = 10000
+	defaultFlushTimeout = 5 * time.Second
+)
+
+var droppedCounter = logger.SinkDroppedCounter
+
+func init() {
+	logger.RegisterSink(SinkKafka, func(config logger.SinkConfig) (logger.Sink, error) {
+		if len(config.Brokers) == 0 || len(config.Topic) == 0 {
+			return nil, fmt.Errorf("brokers and topic are required")
+		}
+		bufferSize := config.BufferSize
+		if bufferSize <= 0 {
+			bufferSize = defaultBufferSize
+		}
+		flushTimeout := time.Duration(config.FlushTimeoutMs) * time.Millisecond
+		if flushTimeout <= 0 {
+			flushTimeout = defaultFlushTimeout
+		}
+		errorHandler := func(err *sarama.ProducerError) {
+			droppedCounter.WithLabelValues(SinkKafka, "error").Inc()
+		}
+		p, err := producer.NewProducer(config.Brokers, producer.WithoutResultLogging(), producer.WithErrorHandler(errorHandler))
+		if err != nil {
+			return nil, fmt.Errorf("fail to create kafka producer: %w", err)
+		}
+		return New(p, config.Topic, bufferSize, flushTimeout), nil
+	})
+}
+
+// Sink writes log entries to a Kafka topic in background. Entries are buffered up to bufferSize,
+// then dropped and counted in logger_sink_dropped_total
+type Sink struct {
+	producer     producer.KafkaProducer
+	topic        string
+	flushTimeout time.Duration
+
+	queue   chan []byte
+	flushes chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	closed  int32
+}
+
+// New creates a sink producing to topic, the producer must not log its results
+func New(p producer.KafkaProducer, topic string, bufferSize int, flushTimeout time.Duration) *Sink {
+	s := &Sink{
+		producer:     p,
+		topic:        topic,
+		flushTimeout: flushTimeout,
+		queue:        make(chan []byte, bufferSize),
+		flushes:      make(chan chan struct{}),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write never blocks, p is copied since zap reuses its buffers
+func (s *Sink) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&s.closed) == 1 {
+		droppedCounter.WithLabelValues(SinkKafka, "closed").Inc()
+		return len(p), nil
+	}
+	entry := make([]byte, len(p))
+	copy(entry, p)
+	select {
+	case s.queue <- entry:
+	default:
+		droppedCounter.WithLabelValues(SinkKafka, "buffer_full").Inc()
+	}
+	return len(p), nil
+}
+
+// Sync waits until the buffered entries are handed to the producer, up to the flush timeout
+func (s *Sink) Sync() error {
+	flushed := make(chan struct{})
+	timer := time.NewTimer(s.flushTimeout)
+	defer timer.Stop()
+	select {
+	case s.flushes <- flushed:
+	case <-s.done:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("kafka log sink flush timeout")
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("kafka log sink flush timeout")
+	}
+}
+
+// Close flushes the buffered entries and closes the producer, which waits for in-flight messages
+func (s *Sink) Close() error {
+	if !atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
+		return nil
+	}
+	close(s.stop)
+	<-s.done
+	s.producer.Close()
+	return nil
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+	for {
+		select {
+		case entry := <-s.queue:
+			s.producer.Produce(s.topic, "", entry)
+		case flushed := <-s.flushes:
+			s.drain()
+			close(flushed)
+		case <-s.stop:
+			s.drain()
+			return
+		}
+	}
+}
+
+func (s *Sink) drain() {
+	for {
+		select {
+		case entry := <-s.queue:
+			s.producer.Produce(s.topic, "", entry)
+		default:
+			return
+		}
+	}
+}
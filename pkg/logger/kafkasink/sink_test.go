@@ -0,0 +1,127 @@
+package kafkasink
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeProducer records the messages, Produce blocks while block is open
+type fakeProducer struct {
+	mutex    sync.Mutex
+	topics   []string
+	messages []string
+	closed   bool
+
+	block     chan struct{}
+	producing chan struct{}
+}
+
+func (p *fakeProducer) Produce(topic string, key string, value []byte) {
+	if p.block != nil {
+		p.producing <- struct{}{}
+		<-p.block
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.topics = append(p.topics, topic)
+	p.messages = append(p.messages, string(value))
+}
+
+func (p *fakeProducer) ProduceCtx(_ context.Context, topic string, key string, value []byte) {
+	p.Produce(topic, key, value)
+}
+
+func (p *fakeProducer) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.closed = true
+}
+
+func (p *fakeProducer) produced() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.messages...)
+}
+
+func TestSinkSync(t *testing.T) {
+	producer := &fakeProducer{}
+	sink := New(producer, "logs", 10, time.Second)
+	defer sink.Close()
+
+	entry := []byte("first")
+	_, _ = sink.Write(entry)
+	// zap reuses its buffers
+	copy(entry, "reuse")
+	_, _ = sink.Write([]byte("second"))
+	if err := sink.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := producer.produced(); len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Errorf("produced %q", got)
+	}
+	if producer.topics[0] != "logs" {
+		t.Errorf("topic = %s", producer.topics[0])
+	}
+}
+
+func TestSinkBufferFull(t *testing.T) {
+	producer := &fakeProducer{block: make(chan struct{}), producing: make(chan struct{}, 10)}
+	sink := New(producer, "logs", 2, 50*time.Millisecond)
+	bufferFull := droppedCounter.WithLabelValues(SinkKafka, "buffer_full")
+	before := testutil.ToFloat64(bufferFull)
+
+	_, _ = sink.Write([]byte("1"))
+	// the producer is busy with the first entry, 2 entries are buffered
+	<-producer.producing
+	for _, entry := range []string{"2", "3", "4", "5"} {
+		_, _ = sink.Write([]byte(entry))
+	}
+	if got := testutil.ToFloat64(bufferFull) - before; got != 2 {
+		t.Errorf("buffer_full = %v, want 2", got)
+	}
+	if err := sink.Sync(); err == nil {
+		t.Error("Sync should time out while the producer is blocked")
+	}
+
+	close(producer.block)
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := producer.produced(); len(got) != 3 {
+		t.Errorf("produced %q, want the 3 buffered entries", got)
+	}
+	if !producer.closed {
+		t.Error("the producer should be closed")
+	}
+}
+
+func TestSinkClosed(t *testing.T) {
+	producer := &fakeProducer{}
+	sink := New(producer, "logs", 10, time.Second)
+	closed := droppedCounter.WithLabelValues(SinkKafka, "closed")
+	before := testutil.ToFloat64(closed)
+
+	_, _ = sink.Write([]byte("before"))
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := sink.Write([]byte("after")); n != len("after") || err != nil {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+	if got := testutil.ToFloat64(closed) - before; got != 1 {
+		t.Errorf("closed = %v, want 1", got)
+	}
+	if err := sink.Sync(); err != nil {
+		t.Errorf("Sync() after Close = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+	if got := producer.produced(); len(got) != 1 || got[0] != "before" {
+		t.Errorf("produced %q, the entries written before Close are flushed", got)
+	}
+}
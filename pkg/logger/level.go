@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RootName is the name of the global logger in level APIs
+const RootName = ""
+
+var levels = newLevelRegistry(zapcore.DebugLevel)
+
+// levelRegistry holds the level of the global logger and the overridden levels of named loggers.
+// A named logger without override uses the level of its closest overridden parent, e.g. kafka.consumer uses kafka
+type levelRegistry struct {
+	root  zap.AtomicLevel
+	named sync.Map // name -> zap.AtomicLevel
+
+	mutex      sync.Mutex
+	configured map[string]zapcore.Level
+	reverts    map[string]*time.Timer
+}
+
+func newLevelRegistry(rootLevel zapcore.Level) *levelRegistry {
+	return &levelRegistry{
+		root:       zap.NewAtomicLevelAt(rootLevel),
+		configured: map[string]zapcore.Level{RootName: rootLevel},
+		reverts:    map[string]*time.Timer{},
+	}
+}
+
+func (r *levelRegistry) enabled(name string, level zapcore.Level) bool {
+	for len(name) > 0 {
+		if named, exist := r.named.Load(name); exist {
+			return named.(zap.AtomicLevel).Enabled(level)
+		}
+		index := strings.LastIndex(name, ".")
+		if index < 0 {
+			break
+		}
+		name = name[:index]
+	}
+	return r.root.Enabled(level)
+}
+
+// set changes the level of name. With a positive ttl, the configured level is restored after ttl
+func (r *levelRegistry) set(name string, level zapcore.Level, ttl time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.apply(name, level)
+	r.stopRevert(name)
+	if ttl > 0 {
+		r.reverts[name] = time.AfterFunc(ttl, func() {
+			r.reset(name)
+		})
+	}
+}
+
+// configure sets the level of name from the configuration, it cancels a pending revert
+func (r *levelRegistry) configure(name string, level zapcore.Level) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.configured[name] = level
+	r.apply(name, level)
+	r.stopRevert(name)
+}
+
+// unconfigure removes the configured level of a named logger
+func (r *levelRegistry) unconfigure(name string) {
+	r.mutex.Lock()
+	delete(r.configured, name)
+	r.mutex.Unlock()
+	r.reset(name)
+}
+
+// reset restores the configured level of name, a named logger without configured level uses its parent level
+func (r *levelRegistry) reset(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stopRevert(name)
+	if level, exist := r.configured[name]; exist {
+		r.apply(name, level)
+		return
+	}
+	r.named.Delete(name)
+}
+
+func (r *levelRegistry) apply(name string, level zapcore.Level) {
+	if name == RootName {
+		r.root.SetLevel(level)
+		return
+	}
+	if named, exist := r.named.Load(name); exist {
+		named.(zap.AtomicLevel).SetLevel(level)
+		return
+	}
+	r.named.Store(name, zap.NewAtomicLevelAt(level))
+}
+
+func (r *levelRegistry) stopRevert(name string) {
+	if timer, exist := r.reverts[name]; exist {
+		timer.Stop()
+		delete(r.reverts, name)
+	}
+}
+
+func (r *levelRegistry) all() map[string]zapcore.Level {
+	all := map[string]zapcore.Level{RootName: r.root.Level()}
+	r.named.Range(func(name, level interface{}) bool {
+		all[name.(string)] = level.(zap.AtomicLevel).Level()
+		return true
+	})
+	return all
+}
+
+func (r *levelRegistry) configuredNames() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	names := make([]string, 0, len(r.configured))
+	for name := range r.configured {
+		names = append(names, name)
+	}
+	return names
+}
+
+// SetLevel changes the level of the logger name (RootName for the global logger) at runtime.
+// With a positive ttl, the configured level is restored after ttl
+func SetLevel(name string, level zapcore.Level, ttl time.Duration) {
+	levels.set(name, level, ttl)
+}
+
+// ResetLevel restores the configured level of the logger name
+func ResetLevel(name string) {
+	levels.reset(name)
+}
+
+// Levels returns the current level of the global logger (RootName) and of every overridden named logger
+func Levels() map[string]zapcore.Level {
+	return levels.all()
+}
+
+// levelCore filters entries by the runtime level of its logger name, the wrapped core accepts every level
+type levelCore struct {
+	zapcore.Core
+	name string
+}
+
+func newLevelCore(core zapcore.Core, name string) zapcore.Core {
+	if named, ok := core.(*levelCore); ok {
+		core = named.Core
+	}
+	return &levelCore{Core: core, name: name}
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return levels.enabled(c.name, level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), name: c.name}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
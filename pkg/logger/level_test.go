@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+)
+
+// isolateLevels replaces the level registry for the duration of t
+func isolateLevels(t *testing.T) {
+	previous := levels
+	levels = newLevelRegistry(zapcore.InfoLevel)
+	t.Cleanup(func() { levels = previous })
+}
+
+func TestSetLevel(t *testing.T) {
+	isolateLevels(t)
+
+	SetLevel("kafka", zapcore.DebugLevel, 0)
+	if !Named("kafka").Named("consumer").Enabled(zapcore.DebugLevel) {
+		t.Error("kafka.consumer should use the level of kafka")
+	}
+	if Named("mysql").Enabled(zapcore.DebugLevel) {
+		t.Error("mysql should use the global level")
+	}
+	SetLevel(RootName, zapcore.ErrorLevel, 0)
+	if Named("mysql").Enabled(zapcore.WarnLevel) {
+		t.Error("mysql should follow the global level")
+	}
+
+	ResetLevel("kafka")
+	ResetLevel(RootName)
+	if got := Levels(); len(got) != 1 || got[RootName] != zapcore.InfoLevel {
+		t.Errorf("Levels() = %v, want the global info level only", got)
+	}
+}
+
+func TestSetLevelTTL(t *testing.T) {
+	isolateLevels(t)
+	levels.configure("kafka", zapcore.WarnLevel)
+
+	SetLevel("kafka", zapcore.DebugLevel, 50*time.Millisecond)
+	SetLevel("mysql", zapcore.DebugLevel, 50*time.Millisecond)
+	if !Named("kafka").Enabled(zapcore.DebugLevel) || !Named("mysql").Enabled(zapcore.DebugLevel) {
+		t.Fatal("the levels are not changed")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for Named("kafka").Enabled(zapcore.DebugLevel) || Named("mysql").Enabled(zapcore.DebugLevel) {
+		if time.Now().After(deadline) {
+			t.Fatal("the levels are not reverted after their ttl")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := Levels(); got["kafka"] != zapcore.WarnLevel {
+		t.Errorf("kafka level = %v, want the configured warn", got["kafka"])
+	}
+	if _, exist := Levels()["mysql"]; exist {
+		t.Error("mysql should use the global level again")
+	}
+}
+
+func TestLoadLevels(t *testing.T) {
+	isolateLevels(t)
+	t.Cleanup(func() {
+		viper.Set("logger.level", nil)
+		viper.Set("logger.levels", nil)
+	})
+
+	viper.Set("logger.level", "warn")
+	viper.Set("logger.levels", []string{"kafka=debug", " redis = error "})
+	if err := LoadLevels(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]zapcore.Level{RootName: zapcore.WarnLevel, "kafka": zapcore.DebugLevel, "redis": zapcore.ErrorLevel}
+	for name, level := range want {
+		if got := Levels()[name]; got != level {
+			t.Errorf("%q level = %v, want %v", name, got, level)
+		}
+	}
+
+	// a runtime change is replaced by the reloaded config
+	SetLevel("redis", zapcore.DebugLevel, time.Hour)
+	viper.Set("logger.levels", []string{"redis=info"})
+	if err := LoadLevels(); err != nil {
+		t.Fatal(err)
+	}
+	if got := Levels(); got["redis"] != zapcore.InfoLevel {
+		t.Errorf("redis level = %v, want info", got["redis"])
+	}
+	if _, exist := Levels()["kafka"]; exist {
+		t.Error("kafka is no longer configured")
+	}
+
+	viper.Set("logger.levels", []string{"kafka"})
+	if err := LoadLevels(); err == nil {
+		t.Error("LoadLevels() without level should fail")
+	}
+}
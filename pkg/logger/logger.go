@@ -2,39 +2,91 @@ package logger
 
 import (
 	"context"
+	"log"
+	"sync/atomic"
 
+	"github.com/spf13/viper"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-var globalLogger *zapLogger
+var (
+	root         atomic.Value // *zap.Logger
+	globalLogger *zapLogger
+	globalSinks  []Sink
+)
 
 func init() {
 	//default logger
-	logger := NewZapLogger(false)
-	globalLogger = &zapLogger{
-		logger: logger,
-	}
+	root.Store(NewZapLogger(false))
+	globalLogger = newRootLogger()
+}
+
+func rootLogger() *zap.Logger {
+	return root.Load().(*zap.Logger)
 }
 
+// InitLogger sets the level of the global logger to info in production, debug otherwise.
+// The global logger is rebuilt from the configuration (sampling, rate limiting, redaction),
+// which is not loaded yet when the default logger is created. It writes to logger.sinks if configured
 func InitLogger(production bool) {
 	if production {
-		logger := NewZapLogger(true)
-		globalLogger.logger = logger
+		levels.configure(RootName, zapcore.InfoLevel)
 	}
+	baggageKeys = viper.GetStringSlice("logger.baggage-keys")
+	sinks, err := Sinks()
+	if err != nil {
+		log.Fatal("Invalid logger.sinks ", err)
+	}
+	logger, outputs, err := NewZapLoggerWithSinks(production, sinks)
+	if err != nil {
+		log.Fatal("Can not create logger ", err)
+	}
+	previousSinks := globalSinks
+	root.Store(logger)
+	globalSinks = outputs
+	closeSinks(previousSinks)
 }
 
 func L() ILogger {
 	return globalLogger
 }
 
+// Ctx returns a logger adding trace_id, span_id, the fields stored by WithFields and the baggage entries
+// listed in logger.baggage-keys
 func Ctx(ctx context.Context) ILogger {
-	span := trace.SpanFromContext(ctx)
-	return &spanLogger{
-		logger: globalLogger.logger,
-		span:   span,
-	}
+	spanContext := trace.SpanContextFromContext(ctx)
+	fields := append([]zapcore.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}, contextFields(ctx)...)
+	fields = append(fields, testOwnerFields(ctx)...)
+	return globalLogger.With(fields...)
 }
 
+// Named returns a child of the global logger named name (e.g. kafka, kafka.consumer),
+// whose level can be changed with SetLevel(name, ...)
+func Named(name string) ILogger {
+	return newNamedLogger(name)
+}
+
+// Sync flushes buffered logs, including the buffer of asynchronous sinks
 func Sync() error {
-	return globalLogger.logger.Sync()
+	return rootLogger().Sync()
+}
+
+// Close closes the sinks of the global logger, logs written after Close are dropped by closed sinks
+func Close() {
+	closeSinks(globalSinks)
+}
+
+// Replace replaces the logger behind L() and Ctx(), e.g. with an observed logger in tests.
+// The returned function restores the previous logger
+func Replace(logger *zap.Logger) func() {
+	previous := rootLogger()
+	root.Store(logger)
+	return func() {
+		root.Store(previous)
+	}
 }
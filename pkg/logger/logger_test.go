@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+)
+
+// initTestLogger applies config with InitLogger in development mode, everything is restored on cleanup
+func initTestLogger(t *testing.T, config map[string]interface{}) {
+	t.Helper()
+	restore := Replace(rootLogger())
+	t.Cleanup(func() {
+		restore()
+		viper.Reset()
+	})
+	for key, value := range config {
+		viper.Set(key, value)
+	}
+	InitLogger(false)
+}
+
+func TestInitLoggerRateLimit(t *testing.T) {
+	initTestLogger(t, map[string]interface{}{
+		"logger.rate-limit.per-call-site": 2,
+		"logger.rate-limit.interval-ms":   60000,
+	})
+	dropped := droppedCounter.WithLabelValues(DropReasonRateLimited, "debug")
+	before := testutil.ToFloat64(dropped)
+	for i := 0; i < 5; i++ {
+		L().Debug("rate limited")
+	}
+	if got := testutil.ToFloat64(dropped) - before; got != 3 {
+		t.Errorf("dropped = %v, want 3 entries above the limit of the call site", got)
+	}
+}
+
+func TestInitLoggerSampling(t *testing.T) {
+	initTestLogger(t, map[string]interface{}{
+		"logger.sampling.enabled":    true,
+		"logger.sampling.tick-ms":    60000,
+		"logger.sampling.initial":    1,
+		"logger.sampling.thereafter": 2,
+	})
+	dropped := droppedCounter.WithLabelValues(DropReasonSampled, "debug")
+	before := testutil.ToFloat64(dropped)
+	for i := 0; i < 5; i++ {
+		L().Debug("sampled")
+	}
+	// the first entry, then every second one
+	if got := testutil.ToFloat64(dropped) - before; got != 2 {
+		t.Errorf("dropped = %v, want 2", got)
+	}
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewLogr returns a logr.Logger writing through l, for libraries logging with logr (e.g. otel, controller-runtime).
+// V(0) logs at info, V(1) and above at debug
+func NewLogr(l ILogger) logr.Logger {
+	return logr.New(&logrSink{logger: l})
+}
+
+type logrSink struct {
+	logger ILogger
+	depth  int
+}
+
+func (s *logrSink) Init(info logr.RuntimeInfo) {
+	s.depth = info.CallDepth
+}
+
+func (s *logrSink) Enabled(level int) bool {
+	return s.logger.Enabled(logrLevel(level))
+}
+
+func (s *logrSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if ce := s.zap().Check(logrLevel(level), msg); ce != nil {
+		ce.Write(keyValueFields(keysAndValues)...)
+	}
+}
+
+func (s *logrSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if ce := s.zap().Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(append(keyValueFields(keysAndValues), zap.Error(err))...)
+	}
+}
+
+func (s *logrSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &logrSink{logger: s.logger.With(keyValueFields(keysAndValues)...), depth: s.depth}
+}
+
+func (s *logrSink) WithName(name string) logr.LogSink {
+	return &logrSink{logger: s.logger.Named(name), depth: s.depth}
+}
+
+func (s *logrSink) WithCallDepth(depth int) logr.LogSink {
+	return &logrSink{logger: s.logger, depth: s.depth + depth}
+}
+
+// zap skips the frames of the sink and of logr.Logger when reporting the caller
+func (s *logrSink) zap() *zap.Logger {
+	logger, _ := zapOf(s.logger)
+	return logger.WithOptions(zap.AddCallerSkip(s.depth))
+}
+
+func logrLevel(level int) zapcore.Level {
+	if level <= 0 {
+		return zapcore.InfoLevel
+	}
+	return zapcore.DebugLevel
+}
+
+// keyValueFields converts loosely typed key-value pairs into fields
+func keyValueFields(keysAndValues []interface{}) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		if i+1 >= len(keysAndValues) {
+			fields = append(fields, zap.String(key, "(MISSING)"))
+			break
+		}
+		fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+	}
+	return fields
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	DropReasonSampled     = "sampled"
+	DropReasonRateLimited = "rate_limited"
+)
+
+var droppedCounter = metrics.Shared(prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "logger_dropped_total",
+	Help: "Number of log entries dropped by sampling or rate limiting",
+}, []string{"reason", "level"})).(*prometheus.CounterVec)
+
+// samplerHook counts the entries dropped by the zap sampler
+func samplerHook(entry zapcore.Entry, decision zapcore.SamplingDecision) {
+	if decision&zapcore.LogDropped != 0 {
+		droppedCounter.WithLabelValues(DropReasonSampled, entry.Level.String()).Inc()
+	}
+}
+
+// rateLimitCore writes at most limit entries per call site and interval, DPanic and above are never dropped.
+// The call site is only known when writing, so the wrapped core is checked again in Write
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *callSiteLimiter
+}
+
+func newRateLimitCore(core zapcore.Core, limit int, interval time.Duration) zapcore.Core {
+	return &rateLimitCore{Core: core, limiter: &callSiteLimiter{
+		limit:    limit,
+		interval: interval,
+		windows:  map[callSite]*window{},
+	}}
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), limiter: c.limiter}
+}
+
+func (c *rateLimitCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	return checked.AddCore(entry, c)
+}
+
+func (c *rateLimitCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level < zapcore.DPanicLevel && !c.limiter.allow(entry) {
+		droppedCounter.WithLabelValues(DropReasonRateLimited, entry.Level.String()).Inc()
+		return nil
+	}
+	// checked by the wrapped core, so that the level of every sink applies
+	if checked := c.Core.Check(entry, nil); checked != nil {
+		checked.Write(fields...)
+	}
+	return nil
+}
+
+type callSite struct {
+	pc      uintptr
+	message string
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+type callSiteLimiter struct {
+	limit    int
+	interval time.Duration
+
+	mutex   sync.Mutex
+	windows map[callSite]*window
+}
+
+// allow keys entries by caller, or by message when the caller is not recorded
+func (l *callSiteLimiter) allow(entry zapcore.Entry) bool {
+	key := callSite{pc: entry.Caller.PC}
+	if !entry.Caller.Defined {
+		key.message = entry.Message
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	w, exist := l.windows[key]
+	if !exist {
+		l.windows[key] = &window{start: entry.Time, count: 1}
+		return true
+	}
+	if entry.Time.Sub(w.start) >= l.interval {
+		w.start, w.count = entry.Time, 1
+		return true
+	}
+	w.count++
+	return w.count <= l.limit
+}
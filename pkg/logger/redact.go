@@ -0,0 +1,397 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	Redacted = "[REDACTED]"
+
+	// RedactTag marks a sensitive struct field: `log:"redact"`
+	RedactTag      = "log"
+	RedactTagValue = "redact"
+
+	PatternEmail  = "email"
+	PatternPhone  = "phone"
+	PatternCard   = "card"
+	PatternBearer = "bearer"
+
+	maxRedactDepth = 16
+	// field number of FieldOptions.debug_redact, read from unknown fields with older protobuf runtimes
+	debugRedactFieldNumber = 16
+)
+
+var (
+	DefaultRedactedFields = []string{
+		"password", "passwd", "secret", "token", "access_token", "refresh_token",
+		"authorization", "client-key", "api-key", "card_number", "cvv",
+	}
+	DefaultRedactionPatterns = []string{PatternEmail, PatternPhone, PatternCard, PatternBearer}
+
+	redactionPatterns = map[string]*regexp.Regexp{
+		PatternEmail:  regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		PatternPhone:  regexp.MustCompile(`\+\d{1,3}[\s.-]?\(?\d{1,4}\)?(?:[\s.-]?\d{2,4}){2,3}\b|\(?\b\d{3}\)?[\s.-]\d{3}[\s.-]\d{4}\b`),
+		PatternCard:   regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		PatternBearer: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	}
+)
+
+// redactor masks sensitive fields (by name, struct tag or proto debug_redact option)
+// and sensitive patterns in string values
+type redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+	card     *regexp.Regexp
+	bearer   *regexp.Regexp
+}
+
+func newRedactor(fields []string, patterns []string, customPatterns []string) (*redactor, error) {
+	r := &redactor{fields: map[string]bool{}}
+	for _, field := range fields {
+		r.fields[normalizeFieldName(field)] = true
+	}
+	for _, name := range patterns {
+		pattern, exist := redactionPatterns[name]
+		if !exist {
+			return nil, fmt.Errorf("unknown redaction pattern %s", name)
+		}
+		switch name {
+		case PatternCard:
+			r.card = pattern
+		case PatternBearer:
+			r.bearer = pattern
+		default:
+			r.patterns = append(r.patterns, pattern)
+		}
+	}
+	for _, custom := range customPatterns {
+		pattern, err := regexp.Compile(custom)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	return r, nil
+}
+
+// normalizeFieldName matches client-key, client_key and ClientKey alike
+func normalizeFieldName(name string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+func (r *redactor) sensitive(name string) bool {
+	return r.fields[normalizeFieldName(name)]
+}
+
+func (r *redactor) redactString(value string) string {
+	if r.bearer != nil {
+		value = r.bearer.ReplaceAllString(value, "Bearer "+Redacted)
+	}
+	if r.card != nil {
+		value = r.card.ReplaceAllStringFunc(value, func(match string) string {
+			if luhn(match) {
+				return Redacted
+			}
+			return match
+		})
+	}
+	for _, pattern := range r.patterns {
+		value = pattern.ReplaceAllString(value, Redacted)
+	}
+	return value
+}
+
+// redactFields returns fields with sensitive values masked, fields is returned as is when nothing is masked
+func (r *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, field := range fields {
+		masked, changed := r.redactField(field)
+		if !changed {
+			if redacted != nil {
+				redacted = append(redacted, field)
+			}
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]zapcore.Field, i, len(fields))
+			copy(redacted, fields[:i])
+		}
+		redacted = append(redacted, masked)
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+func (r *redactor) redactField(field zapcore.Field) (zapcore.Field, bool) {
+	if field.Type == zapcore.NamespaceType || field.Type == zapcore.SkipType {
+		return field, false
+	}
+	if r.sensitive(field.Key) {
+		return zap.String(field.Key, Redacted), true
+	}
+	switch field.Type {
+	case zapcore.StringType:
+		if masked := r.redactString(field.String); masked != field.String {
+			return zap.String(field.Key, masked), true
+		}
+	case zapcore.ByteStringType:
+		value := string(field.Interface.([]byte))
+		if masked := r.redactString(value); masked != value {
+			return zap.String(field.Key, masked), true
+		}
+	case zapcore.StringerType:
+		value := fmt.Sprint(field.Interface)
+		if masked := r.redactString(value); masked != value {
+			return zap.String(field.Key, masked), true
+		}
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			value := err.Error()
+			if masked := r.redactString(value); masked != value {
+				return zap.String(field.Key, masked), true
+			}
+		}
+	case zapcore.ReflectType:
+		return zap.Reflect(field.Key, r.redactValue(reflect.ValueOf(field.Interface), 0)), true
+	}
+	return field, false
+}
+
+// redactValue converts value into maps, slices and scalars with sensitive values masked
+func (r *redactor) redactValue(value reflect.Value, depth int) interface{} {
+	if !value.IsValid() {
+		return nil
+	}
+	if depth > maxRedactDepth {
+		return "[DEPTH]"
+	}
+	if value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		if value.CanInterface() {
+			if msg, ok := value.Interface().(proto.Message); ok {
+				return r.redactMessage(msg.ProtoReflect(), depth)
+			}
+		}
+		return r.redactValue(value.Elem(), depth)
+	}
+	if value.CanInterface() && value.Kind() != reflect.String {
+		switch v := value.Interface().(type) {
+		case json.Marshaler, encoding.TextMarshaler, error:
+			// e.g. time.Time, keep the value encoded by the type
+			return v
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return r.redactString(value.String())
+	case reflect.Struct:
+		return r.redactStruct(value, depth)
+	case reflect.Map:
+		result := make(map[string]interface{}, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if r.sensitive(key) {
+				result[key] = Redacted
+			} else {
+				result[key] = r.redactValue(iter.Value(), depth+1)
+			}
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("[%d bytes]", value.Len())
+		}
+		result := make([]interface{}, value.Len())
+		for i := range result {
+			result[i] = r.redactValue(value.Index(i), depth+1)
+		}
+		return result
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return value.Type().String()
+	}
+	if value.CanInterface() {
+		return value.Interface()
+	}
+	return nil
+}
+
+func (r *redactor) redactStruct(value reflect.Value, depth int) map[string]interface{} {
+	valueType := value.Type()
+	result := make(map[string]interface{}, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if len(tag) > 0 {
+			name = tag
+		}
+		if field.Tag.Get(RedactTag) == RedactTagValue || r.sensitive(name) || r.sensitive(field.Name) {
+			result[name] = Redacted
+			continue
+		}
+		result[name] = r.redactValue(value.Field(i), depth+1)
+	}
+	return result
+}
+
+func (r *redactor) redactMessage(msg protoreflect.Message, depth int) interface{} {
+	if !msg.IsValid() {
+		return nil
+	}
+	result := map[string]interface{}{}
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		name := string(fd.Name())
+		if debugRedact(fd) || r.sensitive(name) {
+			result[name] = Redacted
+			return true
+		}
+		switch {
+		case fd.IsList():
+			list := value.List()
+			items := make([]interface{}, list.Len())
+			for i := range items {
+				items[i] = r.redactProtoValue(fd, list.Get(i), depth+1)
+			}
+			result[name] = items
+		case fd.IsMap():
+			entries := map[string]interface{}{}
+			value.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				if r.sensitive(key.String()) {
+					entries[key.String()] = Redacted
+				} else {
+					entries[key.String()] = r.redactProtoValue(fd.MapValue(), value, depth+1)
+				}
+				return true
+			})
+			result[name] = entries
+		default:
+			result[name] = r.redactProtoValue(fd, value, depth+1)
+		}
+		return true
+	})
+	return result
+}
+
+func (r *redactor) redactProtoValue(fd protoreflect.FieldDescriptor, value protoreflect.Value, depth int) interface{} {
+	if depth > maxRedactDepth {
+		return "[DEPTH]"
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return r.redactMessage(value.Message(), depth)
+	case protoreflect.StringKind:
+		return r.redactString(value.String())
+	case protoreflect.BytesKind:
+		return fmt.Sprintf("[%d bytes]", len(value.Bytes()))
+	case protoreflect.EnumKind:
+		if enum := fd.Enum().Values().ByNumber(value.Enum()); enum != nil {
+			return string(enum.Name())
+		}
+		return value.Enum()
+	}
+	return value.Interface()
+}
+
+// debugRedact reports whether the field is annotated with [debug_redact = true]
+func debugRedact(fd protoreflect.FieldDescriptor) bool {
+	options, ok := fd.Options().(proto.Message)
+	if !ok || options == nil {
+		return false
+	}
+	message := options.ProtoReflect()
+	if known := message.Descriptor().Fields().ByNumber(debugRedactFieldNumber); known != nil {
+		return message.Has(known) && message.Get(known).Bool()
+	}
+	unknown := message.GetUnknown()
+	for len(unknown) > 0 {
+		number, wireType, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return false
+		}
+		unknown = unknown[n:]
+		if number == debugRedactFieldNumber && wireType == protowire.VarintType {
+			value, m := protowire.ConsumeVarint(unknown)
+			return m > 0 && value != 0
+		}
+		m := protowire.ConsumeFieldValue(number, wireType, unknown)
+		if m < 0 {
+			return false
+		}
+		unknown = unknown[m:]
+	}
+	return false
+}
+
+// luhn validates card numbers, to avoid masking other long numbers
+func luhn(number string) bool {
+	sum, double, digits := 0, false, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// redactCore masks sensitive values before they reach the encoders.
+// Fields of zapcore.ObjectMarshaler are only masked by name
+type redactCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+func newRedactCore(core zapcore.Core, redactor *redactor) zapcore.Core {
+	return &redactCore{Core: core, redactor: redactor}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.redactFields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	return checked.AddCore(entry, c)
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.redactString(entry.Message)
+	// checked by the wrapped core, so that the level of every sink applies
+	if checked := c.Core.Check(entry, nil); checked != nil {
+		checked.Write(c.redactor.redactFields(fields)...)
+	}
+	return nil
+}
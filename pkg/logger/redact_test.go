@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observeRedacted returns a logger wrapped like the global one with the logger.redaction.* config
+func observeRedacted(t testing.TB, config map[string]interface{}) (*zap.Logger, *observer.ObservedLogs) {
+	t.Helper()
+	t.Cleanup(viper.Reset)
+	for key, value := range config {
+		viper.Set(key, value)
+	}
+	wrap, err := wrapCore(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(wrap(core)), logs
+}
+
+func TestRedactFieldNames(t *testing.T) {
+	log, logs := observeRedacted(t, map[string]interface{}{
+		"logger.redaction.fields": []string{"client-key", "pin"},
+	})
+	log.Info("login",
+		zap.String("ClientKey", "k1"),
+		zap.Int("pin", 1234),
+		zap.String("user", "bob"),
+		zap.Any("headers", map[string]string{"client_key": "k2", "accept": "json"}),
+	)
+	fields := logs.All()[0].ContextMap()
+	if fields["ClientKey"] != Redacted || fields["pin"] != Redacted {
+		t.Errorf("sensitive fields not redacted: %v", fields)
+	}
+	if fields["user"] != "bob" {
+		t.Errorf("user = %v, want bob", fields["user"])
+	}
+	headers := fields["headers"].(map[string]interface{})
+	if headers["client_key"] != Redacted || headers["accept"] != "json" {
+		t.Errorf("headers = %v", headers)
+	}
+}
+
+func TestRedactStructTag(t *testing.T) {
+	type account struct {
+		Name   string `json:"name"`
+		Secret string `json:"code" log:"redact"`
+		Token  string
+		hidden string
+	}
+	log, logs := observeRedacted(t, nil)
+	log.Info("account", zap.Any("account", &account{Name: "bob", Secret: "s", Token: "t", hidden: "h"}))
+	got := logs.All()[0].ContextMap()["account"].(map[string]interface{})
+	if got["name"] != "bob" || got["code"] != Redacted || got["Token"] != Redacted {
+		t.Errorf("account = %v", got)
+	}
+	if _, exist := got["hidden"]; exist {
+		t.Errorf("unexported field logged: %v", got)
+	}
+}
+
+func TestRedactPatterns(t *testing.T) {
+	log, logs := observeRedacted(t, map[string]interface{}{
+		"logger.redaction.custom-patterns": []string{`ID-\d{4}`},
+	})
+	log.Info("mail bob@example.com",
+		zap.String("header", "Bearer abc.def-ghi"),
+		zap.Error(errors.New("call +84 912 345 678 failed")),
+		zap.ByteString("body", []byte("ref ID-1234")),
+	)
+	entry := logs.All()[0]
+	if entry.Message != "mail "+Redacted {
+		t.Errorf("message = %q", entry.Message)
+	}
+	fields := entry.ContextMap()
+	want := map[string]interface{}{
+		"header": "Bearer " + Redacted,
+		"error":  "call " + Redacted + " failed",
+		"body":   "ref " + Redacted,
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("%s = %q, want %q", key, fields[key], value)
+		}
+	}
+}
+
+func TestRedactCardLuhn(t *testing.T) {
+	log, logs := observeRedacted(t, map[string]interface{}{
+		"logger.redaction.patterns": []string{PatternCard},
+	})
+	log.Info("payment",
+		zap.String("card", "card 4111 1111 1111 1111"),
+		zap.String("order", "order 1234567890123"),
+	)
+	fields := logs.All()[0].ContextMap()
+	if fields["card"] != "card "+Redacted {
+		t.Errorf("card = %q", fields["card"])
+	}
+	// 13 digits failing the Luhn check are kept
+	if fields["order"] != "order 1234567890123" {
+		t.Errorf("order = %q", fields["order"])
+	}
+}
+
+func TestRedactionDisabled(t *testing.T) {
+	log, logs := observeRedacted(t, map[string]interface{}{
+		"logger.redaction.enabled": false,
+	})
+	log.Info("login", zap.String("password", "p"))
+	if got := logs.All()[0].ContextMap()["password"]; got != "p" {
+		t.Errorf("password = %v, want p", got)
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	tests := map[string]bool{
+		"4111111111111111":    true,
+		"4111-1111-1111-1111": true,
+		"5500 0000 0000 0004": true,
+		"4111111111111112":    false,
+		"0000000000":          false,
+	}
+	for number, want := range tests {
+		if got := luhn(number); got != want {
+			t.Errorf("luhn(%q) = %v, want %v", number, got, want)
+		}
+	}
+}
+
+func BenchmarkRedactCore(b *testing.B) {
+	type request struct {
+		User     string
+		Password string
+		Note     string
+	}
+	fields := []zapcore.Field{
+		zap.String("user", "bob"),
+		zap.String("authorization", "Bearer abc"),
+		zap.String("note", "contact bob@example.com"),
+		zap.Int("amount", 100),
+		zap.Any("request", request{User: "bob", Password: "p", Note: "card 4111 1111 1111 1111"}),
+	}
+	redactor, err := newRedactor(DefaultRedactedFields, DefaultRedactionPatterns, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zapcore.DebugLevel)
+	b.Run("plain", func(b *testing.B) {
+		log := zap.New(core)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			log.Info("request", fields...)
+		}
+	})
+	b.Run("redacted", func(b *testing.B) {
+		log := zap.New(newRedactCore(core, redactor))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			log.Info("request", fields...)
+		}
+	})
+}
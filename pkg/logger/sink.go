@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	SinkConsole = "console"
+	SinkFile    = "file"
+
+	EncoderJSON    = "json"
+	EncoderConsole = "console"
+)
+
+// SinkConfig is an entry of logger.sinks
+type SinkConfig struct {
+	// Type is console, file or a type registered with RegisterSink (kafka by pkg/logger/kafkasink)
+	Type string `mapstructure:"type"`
+	// Encoder is json or console, json by default in production
+	Encoder string `mapstructure:"encoder"`
+	// Level is the minimum level written to the sink, debug by default
+	Level string `mapstructure:"level"`
+
+	// Output of console sinks: stdout or stderr (default)
+	Output string `mapstructure:"output"`
+
+	// Path of file sinks, rotated after MaxSizeMB (default 100)
+	Path       string `mapstructure:"path"`
+	MaxSizeMB  int    `mapstructure:"max-size-mb"`
+	MaxAgeDays int    `mapstructure:"max-age-days"`
+	MaxBackups int    `mapstructure:"max-backups"`
+	Compress   bool   `mapstructure:"compress"`
+
+	// Brokers and Topic of kafka sinks, messages are buffered up to BufferSize then dropped
+	Brokers        []string `mapstructure:"brokers"`
+	Topic          string   `mapstructure:"topic"`
+	BufferSize     int      `mapstructure:"buffer-size"`
+	FlushTimeoutMs int      `mapstructure:"flush-timeout-ms"`
+}
+
+// Sink is an output of the logger, closed when the logger is closed
+type Sink interface {
+	zapcore.WriteSyncer
+	io.Closer
+}
+
+type SinkFactory func(config SinkConfig) (Sink, error)
+
+var (
+	sinkFactoriesMutex sync.RWMutex
+	sinkFactories      = map[string]SinkFactory{
+		SinkConsole: newConsoleSink,
+		SinkFile:    newFileSink,
+	}
+)
+
+// RegisterSink makes sinkType usable in logger.sinks
+func RegisterSink(sinkType string, factory SinkFactory) {
+	sinkFactoriesMutex.Lock()
+	defer sinkFactoriesMutex.Unlock()
+	sinkFactories[sinkType] = factory
+}
+
+// Sinks returns logger.sinks
+func Sinks() ([]SinkConfig, error) {
+	var sinks []SinkConfig
+	if err := viper.UnmarshalKey("logger.sinks", &sinks); err != nil {
+		return nil, err
+	}
+	return sinks, nil
+}
+
+// SinkDroppedCounter counts the entries dropped by the sinks, and the sinks skipped because they can't be created
+var SinkDroppedCounter = metrics.Shared(prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "logger_sink_dropped_total",
+	Help: "Number of log entries dropped by asynchronous sinks, and of sinks not created (reason unavailable)",
+}, []string{"sink", "reason"})).(*prometheus.CounterVec)
+
+// SinkUnavailable is the reason of the sinks skipped because their factory failed
+const SinkUnavailable = "unavailable"
+
+// newSinkCores builds a core per sink, with its own encoder and level. A sink whose factory fails,
+// e.g. a remote sink whose servers are unreachable, is skipped so that the other sinks keep working
+func newSinkCores(production bool, configs []SinkConfig) ([]zapcore.Core, []Sink, error) {
+	cores := make([]zapcore.Core, 0, len(configs))
+	sinks := make([]Sink, 0, len(configs))
+	for _, config := range configs {
+		encoder, level, factory, err := sinkSettings(production, config)
+		if err != nil {
+			closeSinks(sinks)
+			return nil, nil, fmt.Errorf("invalid %s log sink: %w", config.Type, err)
+		}
+		sink, err := factory(config)
+		if err != nil {
+			log.Println("Skip the", config.Type, "log sink, it can not be created:", err.Error())
+			SinkDroppedCounter.WithLabelValues(config.Type, SinkUnavailable).Inc()
+			continue
+		}
+		cores = append(cores, zapcore.NewCore(encoder, sink, level))
+		sinks = append(sinks, sink)
+	}
+	return cores, sinks, nil
+}
+
+// sinkSettings returns the encoder, level and factory of a sink, or the error of an invalid config
+func sinkSettings(production bool, config SinkConfig) (zapcore.Encoder, zapcore.Level, SinkFactory, error) {
+	level := zapcore.DebugLevel
+	if len(config.Level) > 0 {
+		var err error
+		if level, err = zapcore.ParseLevel(config.Level); err != nil {
+			return nil, level, nil, err
+		}
+	}
+
+	encoderConfig := getConfig(production).EncoderConfig
+	if config.Type != SinkConsole {
+		// no color outside of terminals
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+	var encoder zapcore.Encoder
+	switch config.Encoder {
+	case EncoderJSON:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case EncoderConsole:
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	case "":
+		if production {
+			encoder = zapcore.NewJSONEncoder(encoderConfig)
+		} else {
+			encoder = zapcore.NewConsoleEncoder(encoderConfig)
+		}
+	default:
+		return nil, level, nil, fmt.Errorf("unknown encoder %s", config.Encoder)
+	}
+
+	sinkFactoriesMutex.RLock()
+	factory, exist := sinkFactories[config.Type]
+	sinkFactoriesMutex.RUnlock()
+	if !exist {
+		return nil, level, nil, fmt.Errorf("unknown sink type %s", config.Type)
+	}
+	return encoder, level, factory, nil
+}
+
+func closeSinks(sinks []Sink) {
+	for _, sink := range sinks {
+		_ = sink.Close()
+	}
+}
+
+type consoleSink struct {
+	*os.File
+}
+
+// Sync is a no-op, writes are not buffered and terminals do not support fsync
+func (s consoleSink) Sync() error {
+	return nil
+}
+
+// Close does not close stdout or stderr
+func (s consoleSink) Close() error {
+	return nil
+}
+
+func newConsoleSink(config SinkConfig) (Sink, error) {
+	switch config.Output {
+	case "stdout":
+		return consoleSink{os.Stdout}, nil
+	case "stderr", "":
+		return consoleSink{os.Stderr}, nil
+	}
+	return nil, fmt.Errorf("unknown console output %s", config.Output)
+}
+
+type fileSink struct {
+	*lumberjack.Logger
+}
+
+func (s fileSink) Sync() error {
+	return nil
+}
+
+func newFileSink(config SinkConfig) (Sink, error) {
+	if len(config.Path) == 0 {
+		return nil, fmt.Errorf("path is required")
+	}
+	return fileSink{&lumberjack.Logger{
+		Filename:   config.Path,
+		MaxSize:    config.MaxSizeMB,
+		MaxAge:     config.MaxAgeDays,
+		MaxBackups: config.MaxBackups,
+		Compress:   config.Compress,
+	}}, nil
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const sinkUnreachable = "test-unreachable"
+
+func init() {
+	RegisterSink(sinkUnreachable, func(config SinkConfig) (Sink, error) {
+		return nil, errors.New("brokers are unreachable")
+	})
+}
+
+func TestUnavailableSinkSkipped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	skipped := SinkDroppedCounter.WithLabelValues(sinkUnreachable, SinkUnavailable)
+	before := testutil.ToFloat64(skipped)
+
+	logger, sinks, err := NewZapLoggerWithSinks(true, []SinkConfig{
+		{Type: SinkFile, Path: path},
+		{Type: sinkUnreachable},
+	})
+	if err != nil {
+		t.Fatalf("NewZapLoggerWithSinks() = %v, the unavailable sink should be skipped", err)
+	}
+	logger.Info("kept")
+	closeSinks(sinks)
+
+	content, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(content), `"msg":"kept"`) {
+		t.Errorf("file sink content %q, %v", content, err)
+	}
+	if got := testutil.ToFloat64(skipped) - before; got != 1 {
+		t.Errorf("skipped sinks = %v, want 1", got)
+	}
+}
+
+func TestOnlyUnavailableSinks(t *testing.T) {
+	logger, sinks, err := NewZapLoggerWithSinks(false, []SinkConfig{{Type: sinkUnreachable}})
+	if err != nil || logger == nil || len(sinks) != 0 {
+		t.Errorf("NewZapLoggerWithSinks() = %v, %v, %v, want the default outputs", logger, sinks, err)
+	}
+}
+
+func TestInvalidSinkConfig(t *testing.T) {
+	for name, config := range map[string]SinkConfig{
+		"type":    {Type: "unknown"},
+		"encoder": {Type: SinkConsole, Encoder: "xml"},
+		"level":   {Type: SinkConsole, Level: "loud"},
+	} {
+		if _, _, err := NewZapLoggerWithSinks(false, []SinkConfig{config}); err == nil {
+			t.Errorf("%s: invalid config should fail", name)
+		}
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := newFileSink(SinkConfig{Type: SinkFile, Path: filepath.Join(dir, "app.log"), MaxSizeMB: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	// 2.5MB: the file is rotated twice
+	for i := 0; i < 2560; i++ {
+		if _, err := sink.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "app*.log"))
+	if len(files) != 3 {
+		t.Errorf("files = %v, want the current file and 2 backups", files)
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || info.Size() > 1024*1024 {
+			t.Errorf("%s is larger than the max size: %v", file, info.Size())
+		}
+	}
+
+	if _, err := newFileSink(SinkConfig{Type: SinkFile}); err == nil {
+		t.Error("a file sink without path should fail")
+	}
+}
@@ -0,0 +1,111 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewSlogHandler returns a slog.Handler writing through l, e.g. slog.SetDefault(slog.New(logger.NewSlogHandler(logger.L()))).
+// The context given to slog adds the same fields as Ctx
+func NewSlogHandler(l ILogger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+type slogHandler struct {
+	logger ILogger
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	logger, _ := zapOf(h.logger)
+	ce := logger.Check(slogLevel(record.Level), record.Message)
+	if ce == nil {
+		return nil
+	}
+	if !record.Time.IsZero() {
+		ce.Time = record.Time
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	fields := make([]zapcore.Field, 0, record.NumAttrs()+2)
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			fields = append(fields,
+				zap.String("trace_id", spanContext.TraceID().String()),
+				zap.String("span_id", spanContext.SpanID().String()),
+			)
+		}
+		fields = append(fields, contextFields(ctx)...)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, attr)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendAttr(fields, attr)
+	}
+	return &slogHandler{logger: h.logger.With(fields...)}
+}
+
+// WithGroup nests the following attributes under name
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &slogHandler{logger: h.logger.With(zap.Namespace(name))}
+}
+
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	}
+	return zapcore.ErrorLevel
+}
+
+func appendAttr(fields []zapcore.Field, attr slog.Attr) []zapcore.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		if len(attr.Key) == 0 {
+			return fields
+		}
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+	group := attr.Value.Group()
+	if len(attr.Key) == 0 {
+		// inlined group
+		for _, nested := range group {
+			fields = appendAttr(fields, nested)
+		}
+		return fields
+	}
+	return append(fields, zap.Object(attr.Key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for _, field := range appendAttr(nil, slog.Attr{Value: slog.GroupValue(group...)}) {
+			field.AddTo(enc)
+		}
+		return nil
+	})))
+}
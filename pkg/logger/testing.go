@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestLogger captures the entries written through L(), Ctx() and Named() during a test.
+// In parallel tests, log with the logger of L() or with Ctx of a context returned by Context:
+// their entries only reach this TestLogger and the ones of the parent tests. Other entries,
+// e.g. written with the global L(), reach every active TestLogger
+type TestLogger struct {
+	// Logs gives access to the observer API for other queries
+	Logs *observer.ObservedLogs
+	core zapcore.Core
+	name string
+}
+
+// NewTestLogger swaps the global logger for the duration of t, the previous logger is restored
+// once the last active TestLogger is cleaned up
+func NewTestLogger(t testing.TB) *TestLogger {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	testLogger := &TestLogger{Logs: logs, core: core, name: t.Name()}
+	testCores.add(testLogger)
+	t.Cleanup(func() {
+		testCores.remove(testLogger)
+	})
+	return testLogger
+}
+
+type testLoggerKey struct{}
+
+// testOwnerField marks the entries of a TestLogger, it is not encoded
+const testOwnerField = "test_logger"
+
+// Context returns ctx whose Ctx(ctx) logs only reach l and the TestLogger of the parent tests,
+// e.g. the context of the requests of a test server
+func (l *TestLogger) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, testLoggerKey{}, l)
+}
+
+// L returns a logger whose entries only reach l and the TestLogger of the parent tests
+func (l *TestLogger) L() ILogger {
+	return globalLogger.With(testOwner(l))
+}
+
+func testOwner(l *TestLogger) zapcore.Field {
+	return zapcore.Field{Key: testOwnerField, Type: zapcore.SkipType, Interface: l}
+}
+
+// testOwnerFields returns the owner field of the TestLogger of ctx, set by TestLogger.Context
+func testOwnerFields(ctx context.Context) []zapcore.Field {
+	if owner, ok := ctx.Value(testLoggerKey{}).(*TestLogger); ok {
+		return []zapcore.Field{testOwner(owner)}
+	}
+	return nil
+}
+
+// receives tells whether l captures the entries of owner, i.e. l is the logger of the test of owner or of a parent
+func (l *TestLogger) receives(owner *TestLogger) bool {
+	return l == owner || strings.HasPrefix(owner.name, l.name+"/")
+}
+
+// All returns every captured entry
+func (l *TestLogger) All() []observer.LoggedEntry {
+	return l.Logs.All()
+}
+
+// ByLevel returns the entries at level
+func (l *TestLogger) ByLevel(level zapcore.Level) []observer.LoggedEntry {
+	return l.Logs.FilterLevelExact(level).All()
+}
+
+// ByMessage returns the entries with message
+func (l *TestLogger) ByMessage(message string) []observer.LoggedEntry {
+	return l.Logs.FilterMessage(message).All()
+}
+
+// ByField returns the entries having the field key with value, compared as encoded by zap
+// (e.g. int64 for integers, string for strings)
+func (l *TestLogger) ByField(key string, value interface{}) []observer.LoggedEntry {
+	return l.Logs.Filter(func(entry observer.LoggedEntry) bool {
+		actual, exist := entry.ContextMap()[key]
+		return exist && actual == value
+	}).All()
+}
+
+// ByTraceID returns the entries written with the trace_id, i.e. with Ctx() in the trace
+func (l *TestLogger) ByTraceID(traceID string) []observer.LoggedEntry {
+	return l.ByField("trace_id", traceID)
+}
+
+// ByContext returns the entries written in the trace of ctx
+func (l *TestLogger) ByContext(ctx context.Context) []observer.LoggedEntry {
+	return l.ByTraceID(trace.SpanContextFromContext(ctx).TraceID().String())
+}
+
+// Reset drops the captured entries
+func (l *TestLogger) Reset() {
+	l.Logs.TakeAll()
+}
+
+// AssertLogged fails t when no entry of message at level has the given field values
+func (l *TestLogger) AssertLogged(t testing.TB, level zapcore.Level, message string, fields map[string]interface{}) {
+	t.Helper()
+	for _, entry := range l.Logs.FilterLevelExact(level).FilterMessage(message).All() {
+		if hasFields(entry.ContextMap(), fields) {
+			return
+		}
+	}
+	t.Errorf("no %s log %q with fields %v, captured: %v", level, message, fields, l.All())
+}
+
+// AssertNotLogged fails t when an entry of message at level was captured
+func (l *TestLogger) AssertNotLogged(t testing.TB, level zapcore.Level, message string) {
+	t.Helper()
+	if entries := l.Logs.FilterLevelExact(level).FilterMessage(message).All(); len(entries) > 0 {
+		t.Errorf("unexpected %s log %q: %v", level, message, entries)
+	}
+}
+
+func hasFields(actual, expected map[string]interface{}) bool {
+	for key, value := range expected {
+		if actual[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+var testCores = &broadcastCore{}
+
+// broadcastCore writes the entries to the cores of the active test loggers, routed by their owner.
+// It replaces the global logger while at least one test logger is active
+type broadcastCore struct {
+	mutex    sync.RWMutex
+	loggers  []*TestLogger
+	previous *zap.Logger
+}
+
+func (b *broadcastCore) add(logger *TestLogger) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.loggers) == 0 {
+		b.previous = rootLogger()
+		root.Store(zap.New(&broadcastWriter{broadcast: b}, zap.AddCaller(), zap.AddCallerSkip(1)))
+	}
+	b.loggers = append(b.loggers, logger)
+}
+
+func (b *broadcastCore) remove(logger *TestLogger) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i, active := range b.loggers {
+		if active == logger {
+			b.loggers = append(b.loggers[:i:i], b.loggers[i+1:]...)
+			break
+		}
+	}
+	if len(b.loggers) == 0 && b.previous != nil {
+		root.Store(b.previous)
+		b.previous = nil
+	}
+}
+
+// write sends the entry of owner to the loggers receiving it, to every logger without owner
+func (b *broadcastCore) write(owner *TestLogger, entry zapcore.Entry, fields []zapcore.Field) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, logger := range b.loggers {
+		if owner == nil || logger.receives(owner) {
+			_ = logger.core.Write(entry, fields)
+		}
+	}
+}
+
+// broadcastWriter is the zapcore.Core of the global logger while test loggers are active
+type broadcastWriter struct {
+	broadcast *broadcastCore
+	fields    []zapcore.Field
+}
+
+func (w *broadcastWriter) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (w *broadcastWriter) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(w.fields)+len(fields))
+	merged = append(merged, w.fields...)
+	return &broadcastWriter{broadcast: w.broadcast, fields: append(merged, fields...)}
+}
+
+func (w *broadcastWriter) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return checked.AddCore(entry, w)
+}
+
+func (w *broadcastWriter) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if len(w.fields) > 0 {
+		fields = append(append(make([]zapcore.Field, 0, len(w.fields)+len(fields)), w.fields...), fields...)
+	}
+	var owner *TestLogger
+	for _, field := range fields {
+		if field.Key == testOwnerField && field.Type == zapcore.SkipType {
+			owner, _ = field.Interface.(*TestLogger)
+		}
+	}
+	w.broadcast.write(owner, entry, fields)
+	return nil
+}
+
+func (w *broadcastWriter) Sync() error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTestLoggerNested(t *testing.T) {
+	previous := rootLogger()
+	// cleanups run last in first out, after the one of NewTestLogger
+	t.Cleanup(func() {
+		if rootLogger() != previous {
+			t.Error("the global logger is not restored")
+		}
+	})
+	parent := NewTestLogger(t)
+	t.Run("child", func(t *testing.T) {
+		child := NewTestLogger(t)
+		child.L().Info("child entry")
+		child.AssertLogged(t, zapcore.InfoLevel, "child entry", nil)
+	})
+	L().Info("parent entry")
+	parent.AssertLogged(t, zapcore.InfoLevel, "child entry", nil)
+	parent.AssertLogged(t, zapcore.InfoLevel, "parent entry", nil)
+}
+
+func TestTestLoggerParallel(t *testing.T) {
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			logs := NewTestLogger(t)
+			ctx := logs.Context(context.Background())
+			for i := 0; i < 50; i++ {
+				logs.L().Info("direct", zap.String("test", name))
+				Ctx(ctx).Named("child").Info("context", zap.String("test", name))
+			}
+			if got := len(logs.All()); got < 100 {
+				t.Errorf("captured %d entries, want 100", got)
+			}
+			for _, entry := range logs.All() {
+				if test, exist := entry.ContextMap()["test"]; exist && test != name {
+					t.Errorf("captured an entry of %v", test)
+				}
+			}
+			if got := len(logs.ByField("test", name)); got != 100 {
+				t.Errorf("captured %d own entries, want 100", got)
+			}
+		})
+	}
+}
+
+func TestTestLoggerUnowned(t *testing.T) {
+	first, second := NewTestLogger(t), NewTestLogger(t)
+	L().Info("global")
+	first.AssertLogged(t, zapcore.InfoLevel, "global", nil)
+	second.AssertLogged(t, zapcore.InfoLevel, "global", nil)
+	first.L().Info("owned")
+	second.AssertNotLogged(t, zapcore.InfoLevel, "owned")
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"log"
+	"os"
 	"time"
 
 	"github.com/nmtri1912/go-common/utils/timeutils"
@@ -10,24 +11,82 @@ import (
 )
 
 func NewZapLogger(production bool) *zap.Logger {
+	zapLogger, _, err := NewZapLoggerWithSinks(production, nil)
+	if err != nil {
+		log.Fatal("Can not create logger ", err)
+	}
+	return zapLogger
+}
+
+// NewZapLoggerWithSinks creates a logger writing to every sink through zapcore.NewTee,
+// or to the default zap outputs when no sink could be created. The returned sinks must be closed by the caller
+func NewZapLoggerWithSinks(production bool, sinks []SinkConfig) (*zap.Logger, []Sink, error) {
 	config := getConfig(production)
+	// sampling is applied by wrapCore
+	config.Sampling = nil
+	wrap, err := wrapCore(production)
+	if err != nil {
+		return nil, nil, err
+	}
 	// AddCallerSkip to skip report wrapper as caller in log message
-	zapLogger, err := config.Build(
+	options := []zap.Option{
 		zap.AddCallerSkip(1),
-	)
+		zap.WrapCore(wrap),
+	}
+	cores, outputs, err := newSinkCores(production, sinks)
 	if err != nil {
-		log.Fatal("Can not create logger ", err)
+		return nil, nil, err
 	}
-	return zapLogger
+	if len(cores) == 0 {
+		// no sink configured, or none could be created
+		zapLogger, err := config.Build(options...)
+		return zapLogger, nil, err
+	}
+	// same options as zap.Config.Build
+	options = append([]zap.Option{zap.AddCaller(), zap.ErrorOutput(zapcore.Lock(os.Stderr))}, options...)
+	if config.Development {
+		options = append(options, zap.Development(), zap.AddStacktrace(zapcore.WarnLevel))
+	} else {
+		options = append(options, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+	return zap.New(zapcore.NewTee(cores...), options...), outputs, nil
+}
+
+// wrapCore applies, from the outside: runtime levels, sampling (logger.sampling.*),
+// per call site rate limiting (logger.rate-limit.*), redaction (logger.redaction.*) and error hooks
+func wrapCore(production bool) (func(core zapcore.Core) zapcore.Core, error) {
+	sampling := getSamplingConfig(production)
+	rateLimit := getRateLimitConfig()
+	redactor, err := getRedactor()
+	if err != nil {
+		return nil, err
+	}
+	return func(core zapcore.Core) zapcore.Core {
+		core = newHookCore(core)
+		if redactor != nil {
+			core = newRedactCore(core, redactor)
+		}
+		if rateLimit.limit > 0 {
+			core = newRateLimitCore(core, rateLimit.limit, rateLimit.interval)
+		}
+		if sampling.enabled {
+			core = zapcore.NewSamplerWithOptions(core, sampling.tick, sampling.initial, sampling.thereafter,
+				zapcore.SamplerHook(samplerHook))
+		}
+		return newLevelCore(core, RootName)
+	}, nil
 }
 
+// getConfig lets every level through, the level is enforced at runtime by levelCore
 func getConfig(production bool) zap.Config {
 	if production {
 		config := zap.NewProductionConfig()
+		config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
 		config.EncoderConfig.EncodeTime = simpleLogTimeEncoder
 		return config
 	}
 	config := zap.NewDevelopmentConfig()
+	config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
 	config.EncoderConfig.EncodeTime = simpleLogTimeEncoder
 	config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	return config
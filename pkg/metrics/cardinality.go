@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Overflow is the label value replacing the values above the limit of a LabelLimiter
+const Overflow = "other"
+
+var foldedCounter = Shared(prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "metrics_label_values_folded_total",
+	Help: "Observations whose label value was folded into \"other\" by the cardinality limit",
+}, []string{"metric", "label"})).(*prometheus.CounterVec)
+
+// LabelLimiter caps the number of distinct values of the guarded labels of a metric.
+// Once a label has limit values, the new ones are folded into Overflow
+type LabelLimiter struct {
+	metric  string
+	labels  []string
+	limit   int
+	guarded []bool
+
+	mutex  sync.RWMutex
+	values []map[string]struct{}
+}
+
+// NewLabelLimiter creates a limiter of the labels of metric, in the order of the label values.
+// Only the guarded labels are limited, all of them if none, and nothing if limit <= 0.
+// It panics when a guarded label is not one of labels
+func NewLabelLimiter(metric string, labels []string, limit int, guarded ...string) *LabelLimiter {
+	for _, name := range guarded {
+		if !contains(labels, name) {
+			panic(fmt.Sprintf("metrics: guarded label %s is not a label of %s %v", name, metric, labels))
+		}
+	}
+	limiter := &LabelLimiter{
+		metric:  metric,
+		labels:  labels,
+		limit:   limit,
+		guarded: make([]bool, len(labels)),
+		values:  make([]map[string]struct{}, len(labels)),
+	}
+	for i, label := range labels {
+		limiter.guarded[i] = len(guarded) == 0 || contains(guarded, label)
+		if limiter.guarded[i] {
+			limiter.values[i] = make(map[string]struct{})
+		}
+	}
+	return limiter
+}
+
+// Values returns values with the values above the limit replaced by Overflow
+func (l *LabelLimiter) Values(values ...string) []string {
+	if l.limit <= 0 {
+		return values
+	}
+	var folded []string
+	for i, value := range values {
+		if i >= len(l.guarded) || !l.guarded[i] || l.admit(i, value) {
+			continue
+		}
+		if folded == nil {
+			folded = append([]string(nil), values...)
+		}
+		folded[i] = Overflow
+		foldedCounter.WithLabelValues(l.metric, l.labels[i]).Inc()
+	}
+	if folded == nil {
+		return values
+	}
+	return folded
+}
+
+func (l *LabelLimiter) admit(label int, value string) bool {
+	l.mutex.RLock()
+	_, ok := l.values[label][value]
+	l.mutex.RUnlock()
+	if ok {
+		return true
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, ok := l.values[label][value]; ok {
+		return true
+	}
+	if len(l.values[label]) >= l.limit {
+		return false
+	}
+	l.values[label][value] = struct{}{}
+	return true
+}
+
+// LabelVec is a metric vector like *prometheus.CounterVec, returning T by label values
+type LabelVec[T any] interface {
+	WithLabelValues(values ...string) T
+}
+
+// LimitedVec is a metric vector whose label values are capped by a LabelLimiter
+//
+//	requests := metrics.RegisterLimited[prometheus.Counter](registerer, requestsVec, metrics.NewLabelLimiter("requests_total", []string{"code", "url"}, 100, "url"))
+//	requests.WithLabelValues("200", r.URL.Path).Inc()
+type LimitedVec[T any] struct {
+	vec     LabelVec[T]
+	limiter *LabelLimiter
+}
+
+// Limit wraps vec to fold the label values above the limits of limiter
+func Limit[T any](vec LabelVec[T], limiter *LabelLimiter) *LimitedVec[T] {
+	return &LimitedVec[T]{vec: vec, limiter: limiter}
+}
+
+// CollectorVec is a LabelVec which can be registered, like *prometheus.CounterVec
+type CollectorVec[T any] interface {
+	LabelVec[T]
+	prometheus.Collector
+}
+
+// limitedCollector is a vector registered with its limiter by RegisterLimited
+type limitedCollector[T any] struct {
+	CollectorVec[T]
+	limited *LimitedVec[T]
+}
+
+func (c *limitedCollector[T]) registered() prometheus.Collector {
+	return c.CollectorVec
+}
+
+// RegisterLimited registers vec in registerer like Register and returns the registered vector limited by limiter.
+// The limiter is kept with the registered vector: when the vector is shared by several instances, e.g. several
+// middlewares registered in the same registry, it keeps the limiter it was first registered with and their
+// values are counted together
+func RegisterLimited[T any](registerer prometheus.Registerer, vec CollectorVec[T], limiter *LabelLimiter) *LimitedVec[T] {
+	collector := &limitedCollector[T]{CollectorVec: vec, limited: Limit[T](vec, limiter)}
+	err := Registerer(registerer).Register(collector)
+	if err == nil {
+		return collector.limited
+	}
+	registered, ok := err.(prometheus.AlreadyRegisteredError)
+	if !ok {
+		panic(err)
+	}
+	switch existing := registered.ExistingCollector.(type) {
+	case *limitedCollector[T]:
+		return existing.limited
+	case LabelVec[T]:
+		// registered with Register, without limiter
+		return Limit(existing, limiter)
+	}
+	panic(fmt.Sprintf("metrics: %T is registered instead of %T", registered.ExistingCollector, vec))
+}
+
+// Vec returns the wrapped vector
+func (v *LimitedVec[T]) Vec() LabelVec[T] {
+	return v.vec
+}
+
+func (v *LimitedVec[T]) WithLabelValues(values ...string) T {
+	return v.vec.WithLabelValues(v.limiter.Values(values...)...)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
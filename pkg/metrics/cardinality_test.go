@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLabelLimiterFolding(t *testing.T) {
+	limiter := NewLabelLimiter("test_requests_total", []string{"code", "url"}, 2, "url")
+	folded := foldedCounter.WithLabelValues("test_requests_total", "url")
+	before := testutil.ToFloat64(folded)
+
+	tests := []struct {
+		values, want []string
+	}{
+		{[]string{"200", "/a"}, []string{"200", "/a"}},
+		{[]string{"500", "/b"}, []string{"500", "/b"}},
+		{[]string{"200", "/c"}, []string{"200", Overflow}},
+		// known values are kept once the limit is reached
+		{[]string{"404", "/a"}, []string{"404", "/a"}},
+		{[]string{"503", "/d"}, []string{"503", Overflow}},
+	}
+	for _, test := range tests {
+		if got := limiter.Values(test.values...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Values(%v) = %v, want %v", test.values, got, test.want)
+		}
+	}
+	if got := testutil.ToFloat64(folded) - before; got != 2 {
+		t.Errorf("folded = %v, want 2", got)
+	}
+	// the code label is not guarded
+	if got := testutil.ToFloat64(foldedCounter.WithLabelValues("test_requests_total", "code")); got != 0 {
+		t.Errorf("folded code = %v, want 0", got)
+	}
+}
+
+func TestLabelLimiterAllLabels(t *testing.T) {
+	limiter := NewLabelLimiter("test_all_total", []string{"a", "b"}, 1)
+	limiter.Values("a1", "b1")
+	if got := limiter.Values("a2", "b2"); !reflect.DeepEqual(got, []string{Overflow, Overflow}) {
+		t.Errorf("Values() = %v, want every label folded", got)
+	}
+	disabled := NewLabelLimiter("test_disabled_total", []string{"a"}, 0)
+	for _, value := range []string{"1", "2", "3"} {
+		if got := disabled.Values(value); got[0] != value {
+			t.Errorf("disabled limiter folded %s", value)
+		}
+	}
+}
+
+func TestLabelLimiterInvalidGuarded(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewLabelLimiter should panic on an unknown guarded label")
+		}
+	}()
+	NewLabelLimiter("test_typo_total", []string{"code", "url"}, 10, "uri")
+}
+
+func TestRegisterLimitedSharesLimiter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	newCounter := func() *LimitedVec[prometheus.Counter] {
+		vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_shared_limit_total"}, []string{"url"})
+		return RegisterLimited[prometheus.Counter](registry, vec, NewLabelLimiter("test_shared_limit_total", []string{"url"}, 2))
+	}
+	first, second := newCounter(), newCounter()
+	first.WithLabelValues("/a").Inc()
+	second.WithLabelValues("/b").Inc()
+	first.WithLabelValues("/c").Inc()
+	second.WithLabelValues("/d").Inc()
+
+	if count := testutil.CollectAndCount(registry, "test_shared_limit_total"); count != 3 {
+		t.Errorf("%d series, want /a, /b and other", count)
+	}
+	vec := Register(registry, prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_shared_limit_total"}, []string{"url"})).(*prometheus.CounterVec)
+	if got := testutil.ToFloat64(vec.WithLabelValues(Overflow)); got != 2 {
+		t.Errorf("other = %v, want 2", got)
+	}
+
+	// another registry has its own limiter
+	other := RegisterLimited[prometheus.Counter](prometheus.NewRegistry(),
+		prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_shared_limit_total"}, []string{"url"}),
+		NewLabelLimiter("test_shared_limit_total", []string{"url"}, 2))
+	if values := other.limiter.Values("/c"); values[0] != "/c" {
+		t.Errorf("values = %v, want /c admitted", values)
+	}
+}
+
+func TestRegisterLimitedRegisteredVec(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	vec := Register(registry, prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_registered_limit_total"}, []string{"url"})).(*prometheus.CounterVec)
+	limited := RegisterLimited[prometheus.Counter](registry,
+		prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_registered_limit_total"}, []string{"url"}),
+		NewLabelLimiter("test_registered_limit_total", []string{"url"}, 1))
+	if limited.Vec() != LabelVec[prometheus.Counter](vec) {
+		t.Error("the registered vector is not limited")
+	}
+	limited.WithLabelValues("/a").Inc()
+	limited.WithLabelValues("/b").Inc()
+	if got := testutil.ToFloat64(vec.WithLabelValues(Overflow)); got != 1 {
+		t.Errorf("other = %v, want 1", got)
+	}
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var (
+	sharedMutex sync.Mutex
+	shared      []prometheus.Collector
+)
+
+// Register registers collector in registerer, prometheus.DefaultRegisterer if nil.
+// When an equal collector is already registered, the registered one is returned, the vector itself
+// when it was registered with RegisterLimited
+func Register(registerer prometheus.Registerer, collector prometheus.Collector) prometheus.Collector {
+	err := Registerer(registerer).Register(collector)
+	if err == nil {
+		return collector
+	}
+	if registered, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		if limited, ok := registered.ExistingCollector.(interface{ registered() prometheus.Collector }); ok {
+			return limited.registered()
+		}
+		return registered.ExistingCollector
+	}
+	panic(err)
+}
+
+// Shared registers a process wide collector, e.g. a package level counter, in prometheus.DefaultRegisterer
+// and in the registries created by NewRegistry. Other registries only receive the shared collectors
+// with RegisterShared. Call it at package initialization
+func Shared(collector prometheus.Collector) prometheus.Collector {
+	sharedMutex.Lock()
+	defer sharedMutex.Unlock()
+	collector = Register(prometheus.DefaultRegisterer, collector)
+	shared = append(shared, collector)
+	return collector
+}
+
+// RegisterShared registers the collectors declared with Shared in registerer, e.g. a registry not created
+// by NewRegistry. The collectors of packages initialized later are not registered
+func RegisterShared(registerer prometheus.Registerer) {
+	sharedMutex.Lock()
+	defer sharedMutex.Unlock()
+	for _, collector := range shared {
+		Register(registerer, collector)
+	}
+}
+
+// NewRegistry creates a registry with the Go runtime, process and shared collectors
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	RegisterShared(registry)
+	return registry
+}
+
+// Registerer returns registerer, prometheus.DefaultRegisterer if nil
+func Registerer(registerer prometheus.Registerer) prometheus.Registerer {
+	if registerer == nil {
+		return prometheus.DefaultRegisterer
+	}
+	return registerer
+}
+
+// Gatherer returns gatherer, prometheus.DefaultGatherer if nil
+func Gatherer(gatherer prometheus.Gatherer) prometheus.Gatherer {
+	if gatherer == nil {
+		return prometheus.DefaultGatherer
+	}
+	return gatherer
+}
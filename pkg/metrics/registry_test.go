@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var testSharedCounter = Shared(prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "metrics_test_shared_total",
+	Help: "Shared counter of the tests",
+})).(prometheus.Counter)
+
+func TestSharedCollectors(t *testing.T) {
+	testSharedCounter.Inc()
+	registry := NewRegistry()
+	other := prometheus.NewRegistry()
+	RegisterShared(other)
+	for name, gatherer := range map[string]prometheus.Gatherer{
+		"default":     prometheus.DefaultGatherer,
+		"NewRegistry": registry,
+		"other":       other,
+	} {
+		if count, err := testutil.GatherAndCount(gatherer, "metrics_test_shared_total"); err != nil || count != 1 {
+			t.Errorf("%s: %d series, %v", name, count, err)
+		}
+	}
+	// registering twice is a no-op
+	RegisterShared(registry)
+}
@@ -1,5 +1,14 @@
 package monitor
 
+import (
+	"math"
+
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
 var defaultBuckets = []float64{0.001, 0.001048576, 0.001398101, 0.001747626, 0.002097151, 0.002446676,
 	0.002796201, 0.003145726, 0.003495251, 0.003844776, 0.004194304, 0.005592405, 0.006990506,
 	0.008388607, 0.009786708, 0.011184809, 0.01258291, 0.013981011, 0.015379112, 0.016777216,
@@ -11,6 +20,120 @@ var defaultBuckets = []float64{0.001, 0.001048576, 0.001398101, 0.001747626, 0.0
 	4.294967296, 5.726623061, 7.158278826, 8.589934591, 10.021590356, 11.453246121, 12.884901886,
 	14.316557651, 15.748213416, 17.179869184, 22.906492245, 28.633115306, 30}
 
+// Bucket presets, in seconds
+var (
+	// FastRPCBuckets fits in-memory and RPC calls, from 0.5ms to 2.5s
+	FastRPCBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+	// DBBuckets fits database and cache queries, from 1ms to 10s
+	DBBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// BatchBuckets fits jobs and batches, from 100ms to 30min
+	BatchBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+)
+
+// Names of the bucket presets in config
+const (
+	PresetDefault = "default"
+	PresetFastRPC = "fast-rpc"
+	PresetDB      = "db"
+	PresetBatch   = "batch"
+)
+
+// GetHistorgramBuckets returns the default buckets, about 70 buckets from 1ms to 30s
 func GetHistorgramBuckets() []float64 {
 	return defaultBuckets
 }
+
+// PresetBuckets returns the buckets of a preset, false if unknown
+func PresetBuckets(preset string) ([]float64, bool) {
+	switch preset {
+	case PresetDefault:
+		return defaultBuckets, true
+	case PresetFastRPC:
+		return FastRPCBuckets, true
+	case PresetDB:
+		return DBBuckets, true
+	case PresetBatch:
+		return BatchBuckets, true
+	default:
+		return nil, false
+	}
+}
+
+// NativeHistogramOpts enables Prometheus native histograms, exposed in addition to the classic buckets
+// to scrapers requesting the protobuf format
+type NativeHistogramOpts struct {
+	// Schema sets the resolution, a bucket is 2^(2^-Schema) times larger than the previous one.
+	// From -4 (factor 65536) to 8 (factor 1.0027), e.g. 3 is a factor of 1.09
+	Schema int
+	// ZeroThreshold is the width of the zero bucket. 0 uses the client default
+	ZeroThreshold float64
+	// MaxBuckets halves the resolution when exceeded. 0 means no limit
+	MaxBuckets uint32
+}
+
+// HistogramSettings are the buckets of a histogram, and its native histogram options if enabled
+type HistogramSettings struct {
+	Buckets []float64
+	Native  *NativeHistogramOpts
+}
+
+// Apply sets the buckets and the native histogram options of opts
+func (s HistogramSettings) Apply(opts *prometheus.HistogramOpts) {
+	opts.Buckets = s.Buckets
+	if s.Native == nil {
+		return
+	}
+	schema := s.Native.Schema
+	if schema < -4 {
+		schema = -4
+	} else if schema > 8 {
+		schema = 8
+	}
+	// the client picks the highest resolution whose factor is not above the given one, the margin
+	// keeps the schema from being rounded up
+	opts.NativeHistogramBucketFactor = math.Pow(2, math.Pow(2, -float64(schema))) * (1 + 1e-9)
+	opts.NativeHistogramZeroThreshold = s.Native.ZeroThreshold
+	opts.NativeHistogramMaxBucketNumber = s.Native.MaxBuckets
+}
+
+type histogramConfig struct {
+	Preset              string    `mapstructure:"preset"`
+	Buckets             []float64 `mapstructure:"buckets"`
+	Native              bool      `mapstructure:"native"`
+	NativeSchema        *int      `mapstructure:"native-schema"`
+	NativeZeroThreshold float64   `mapstructure:"native-zero-threshold"`
+	NativeMaxBuckets    uint32    `mapstructure:"native-max-buckets"`
+}
+
+const defaultNativeSchema = 3
+
+// LoadHistogramSettings reads monitor.histograms.<name>, defaultBuckets are used when neither
+// a preset nor buckets are configured
+func LoadHistogramSettings(name string, defaultBuckets []float64) HistogramSettings {
+	settings := HistogramSettings{Buckets: defaultBuckets}
+	var config histogramConfig
+	if err := viper.UnmarshalKey("monitor.histograms."+name, &config); err != nil {
+		logger.L().Warn("Load histogram config has error: ", zap.String("histogram", name), zap.Error(err))
+		return settings
+	}
+	if len(config.Buckets) > 0 {
+		settings.Buckets = config.Buckets
+	} else if len(config.Preset) > 0 {
+		if buckets, ok := PresetBuckets(config.Preset); ok {
+			settings.Buckets = buckets
+		} else {
+			logger.L().Warn("Unknown histogram bucket preset", zap.String("histogram", name), zap.String("preset", config.Preset))
+		}
+	}
+	if config.Native {
+		settings.Native = &NativeHistogramOpts{
+			Schema:        defaultNativeSchema,
+			ZeroThreshold: config.NativeZeroThreshold,
+			MaxBuckets:    config.NativeMaxBuckets,
+		}
+		if config.NativeSchema != nil {
+			settings.Native.Schema = *config.NativeSchema
+		}
+	}
+	return settings
+}
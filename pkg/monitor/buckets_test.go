@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestHistogramSettingsApplyNativeSchema(t *testing.T) {
+	for configured, want := range map[int]int32{-6: -4, -2: -2, 0: 0, 3: 3, 8: 8, 10: 8} {
+		opts := prometheus.HistogramOpts{Name: "test_native_seconds"}
+		HistogramSettings{Buckets: DBBuckets, Native: &NativeHistogramOpts{Schema: configured}}.Apply(&opts)
+		histogram := prometheus.NewHistogram(opts)
+		for _, value := range []float64{0.003, 0.02, 0.7, 4} {
+			histogram.Observe(value)
+		}
+		var metric dto.Metric
+		if err := histogram.Write(&metric); err != nil {
+			t.Fatal(err)
+		}
+		if got := metric.GetHistogram().GetSchema(); got != want {
+			t.Errorf("schema %d: gathered schema %d, want %d", configured, got, want)
+		}
+		if got := len(metric.GetHistogram().GetBucket()); got != len(DBBuckets) {
+			t.Errorf("schema %d: %d classic buckets, want %d", configured, got, len(DBBuckets))
+		}
+	}
+}
+
+func setHistogramConfig(t *testing.T, name string, config map[string]interface{}) {
+	t.Helper()
+	key := "monitor.histograms." + name
+	viper.Set(key, config)
+	t.Cleanup(func() { viper.Set(key, nil) })
+}
+
+func TestLoadHistogramSettings(t *testing.T) {
+	defaults := []float64{1, 2}
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   HistogramSettings
+	}{
+		{name: "none", want: HistogramSettings{Buckets: defaults}},
+		{name: "preset", config: map[string]interface{}{"preset": PresetBatch}, want: HistogramSettings{Buckets: BatchBuckets}},
+		{
+			name:   "buckets override the preset",
+			config: map[string]interface{}{"preset": PresetDB, "buckets": []float64{0.1, 0.2}},
+			want:   HistogramSettings{Buckets: []float64{0.1, 0.2}},
+		},
+		{
+			name:   "native",
+			config: map[string]interface{}{"native": true, "native-max-buckets": 100},
+			want:   HistogramSettings{Buckets: defaults, Native: &NativeHistogramOpts{Schema: defaultNativeSchema, MaxBuckets: 100}},
+		},
+		{
+			name:   "native schema 0",
+			config: map[string]interface{}{"native": true, "native-schema": 0, "native-zero-threshold": 0.001},
+			want:   HistogramSettings{Buckets: defaults, Native: &NativeHistogramOpts{Schema: 0, ZeroThreshold: 0.001}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setHistogramConfig(t, "test", test.config)
+			if got := LoadHistogramSettings("test", defaults); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("LoadHistogramSettings() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadHistogramSettingsUnknownPreset(t *testing.T) {
+	logs := logger.NewTestLogger(t)
+	setHistogramConfig(t, "test", map[string]interface{}{"preset": "slow"})
+	defaults := []float64{1, 2}
+	if got := LoadHistogramSettings("test", defaults); !reflect.DeepEqual(got.Buckets, defaults) {
+		t.Errorf("buckets = %v, want the defaults", got.Buckets)
+	}
+	logs.AssertLogged(t, zapcore.WarnLevel, "Unknown histogram bucket preset", map[string]interface{}{"histogram": "test", "preset": "slow"})
+}
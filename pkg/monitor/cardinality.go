@@ -0,0 +1,23 @@
+package monitor
+
+import (
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/spf13/viper"
+)
+
+const defaultLabelLimit = 100
+
+// LabelLimit returns the maximum number of distinct values of a label of metric,
+// monitor.cardinality.limits.<metric> or else monitor.cardinality.limit. 0 or less disables the limit
+func LabelLimit(metric string) int {
+	viper.SetDefault("monitor.cardinality.limit", defaultLabelLimit)
+	if key := "monitor.cardinality.limits." + metric; viper.IsSet(key) {
+		return viper.GetInt(key)
+	}
+	return viper.GetInt("monitor.cardinality.limit")
+}
+
+// NewLabelLimiter creates the limiter of the guarded labels of metric, all of them if none, with the configured LabelLimit
+func NewLabelLimiter(metric string, labels []string, guarded ...string) *metrics.LabelLimiter {
+	return metrics.NewLabelLimiter(metric, labels, LabelLimit(metric), guarded...)
+}
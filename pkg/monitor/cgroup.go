@@ -0,0 +1,147 @@
+package monitor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cgroupRoot is the mount point of the cgroup file systems
+var cgroupRoot = "/sys/fs/cgroup"
+
+// CgroupLimits are the resources granted to the process by its cgroup, on Linux
+type CgroupLimits struct {
+	// CPUQuota is the number of CPUs of the quota, 0 without quota
+	CPUQuota float64
+	// MemoryLimit is in bytes, 0 without limit
+	MemoryLimit int64
+	// Periods, ThrottledPeriods and ThrottledSeconds are cumulative CPU throttling counters
+	Periods          uint64
+	ThrottledPeriods uint64
+	ThrottledSeconds float64
+}
+
+// ReadCgroupLimits reads the cgroup of the process, cgroup v2 or v1. False when not in a cgroup
+func ReadCgroupLimits() (CgroupLimits, bool) {
+	paths := readProcCgroup()
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		return readCgroupV2(cgroupDir(cgroupRoot, paths[""])), true
+	}
+	cpuDir, cpuFound := cgroupV1Dir("cpu", paths)
+	memoryDir, memoryFound := cgroupV1Dir("memory", paths)
+	if !cpuFound && !memoryFound {
+		return CgroupLimits{}, false
+	}
+	return readCgroupV1(cpuDir, memoryDir), true
+}
+
+func readCgroupV2(dir string) CgroupLimits {
+	var limits CgroupLimits
+	// cpu.max is "$MAX $PERIOD", $MAX is "max" without quota
+	if fields := strings.Fields(readFile(filepath.Join(dir, "cpu.max"))); len(fields) == 2 && fields[0] != "max" {
+		limits.CPUQuota = ratio(fields[0], fields[1])
+	}
+	if memory := readFile(filepath.Join(dir, "memory.max")); memory != "max" {
+		limits.MemoryLimit, _ = strconv.ParseInt(memory, 10, 64)
+	}
+	stat := readStat(filepath.Join(dir, "cpu.stat"))
+	limits.Periods = stat["nr_periods"]
+	limits.ThrottledPeriods = stat["nr_throttled"]
+	limits.ThrottledSeconds = float64(stat["throttled_usec"]) / 1e6
+	return limits
+}
+
+func readCgroupV1(cpuDir, memoryDir string) CgroupLimits {
+	var limits CgroupLimits
+	// cfs_quota_us is -1 without quota
+	quota := readFile(filepath.Join(cpuDir, "cpu.cfs_quota_us"))
+	if len(quota) > 0 && !strings.HasPrefix(quota, "-") {
+		limits.CPUQuota = ratio(quota, readFile(filepath.Join(cpuDir, "cpu.cfs_period_us")))
+	}
+	// without limit, memory.limit_in_bytes is close to the max int64, rounded to the page size
+	if memory, err := strconv.ParseInt(readFile(filepath.Join(memoryDir, "memory.limit_in_bytes")), 10, 64); err == nil && memory < 1<<62 {
+		limits.MemoryLimit = memory
+	}
+	stat := readStat(filepath.Join(cpuDir, "cpu.stat"))
+	limits.Periods = stat["nr_periods"]
+	limits.ThrottledPeriods = stat["nr_throttled"]
+	limits.ThrottledSeconds = float64(stat["throttled_time"]) / 1e9
+	return limits
+}
+
+// readProcCgroup returns the cgroup path of the process by controller, "" for cgroup v2
+func readProcCgroup() map[string]string {
+	paths := make(map[string]string)
+	file, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return paths
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths
+}
+
+// cgroupDir returns the directory of the process cgroup under mount, or mount itself when the path
+// isn't visible, e.g. in a container without cgroup namespace
+func cgroupDir(mount, path string) string {
+	dir := filepath.Join(mount, path)
+	if _, err := os.Stat(dir); err == nil {
+		return dir
+	}
+	return mount
+}
+
+func cgroupV1Dir(controller string, paths map[string]string) (string, bool) {
+	mount := filepath.Join(cgroupRoot, controller)
+	if _, err := os.Stat(mount); err != nil {
+		return "", false
+	}
+	return cgroupDir(mount, paths[controller]), true
+}
+
+func ratio(numerator, denominator string) float64 {
+	n, err := strconv.ParseFloat(numerator, 64)
+	if err != nil {
+		return 0
+	}
+	d, err := strconv.ParseFloat(denominator, 64)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return n / d
+}
+
+func readFile(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// readStat parses a file of "key value" lines
+func readStat(path string) map[string]uint64 {
+	stat := make(map[string]uint64)
+	for _, line := range strings.Split(readFile(path), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if value, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			stat[fields[0]] = value
+		}
+	}
+	return stat
+}
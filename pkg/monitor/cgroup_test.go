@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cgroupFixture writes files under a temporary cgroupRoot, restored when t ends
+func cgroupFixture(t *testing.T, files map[string]string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = previous })
+}
+
+func TestReadCgroupLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  CgroupLimits
+	}{{
+		name: "v2",
+		files: map[string]string{
+			"cgroup.controllers": "cpu memory",
+			"cpu.max":            "150000 100000",
+			"memory.max":         "536870912",
+			"cpu.stat":           "usage_usec 9000\nnr_periods 10\nnr_throttled 4\nthrottled_usec 2500000",
+		},
+		want: CgroupLimits{CPUQuota: 1.5, MemoryLimit: 536870912, Periods: 10, ThrottledPeriods: 4, ThrottledSeconds: 2.5},
+	}, {
+		name: "v2 max quota and unlimited memory",
+		files: map[string]string{
+			"cgroup.controllers": "cpu memory",
+			"cpu.max":            "max 100000",
+			"memory.max":         "max",
+		},
+		want: CgroupLimits{},
+	}, {
+		name: "v1",
+		files: map[string]string{
+			"cpu/cpu.cfs_quota_us":         "200000",
+			"cpu/cpu.cfs_period_us":        "100000",
+			"cpu/cpu.stat":                 "nr_periods 5\nnr_throttled 1\nthrottled_time 500000000",
+			"memory/memory.limit_in_bytes": "1073741824",
+		},
+		want: CgroupLimits{CPUQuota: 2, MemoryLimit: 1073741824, Periods: 5, ThrottledPeriods: 1, ThrottledSeconds: 0.5},
+	}, {
+		name: "v1 without quota and unlimited memory",
+		files: map[string]string{
+			"cpu/cpu.cfs_quota_us":         "-1",
+			"cpu/cpu.cfs_period_us":        "100000",
+			"memory/memory.limit_in_bytes": "9223372036854771712",
+		},
+		want: CgroupLimits{},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cgroupFixture(t, test.files)
+			limits, ok := ReadCgroupLimits()
+			if !ok {
+				t.Fatal("the cgroup is not found")
+			}
+			if limits != test.want {
+				t.Errorf("ReadCgroupLimits() = %+v, want %+v", limits, test.want)
+			}
+		})
+	}
+}
+
+func TestReadCgroupLimitsWithoutCgroup(t *testing.T) {
+	cgroupFixture(t, nil)
+	if limits, ok := ReadCgroupLimits(); ok {
+		t.Errorf("ReadCgroupLimits() = %+v, want no cgroup", limits)
+	}
+}
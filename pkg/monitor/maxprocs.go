@@ -0,0 +1,33 @@
+package monitor
+
+import (
+	"math"
+	"os"
+	"runtime"
+)
+
+// SetMaxProcsFromCgroup sets GOMAXPROCS to the CPU quota of the cgroup, rounded down, at least 1
+// and at most the number of CPUs.
+// GOMAXPROCS is left unchanged when set by the environment or without quota.
+// It returns the GOMAXPROCS in effect and whether it was changed
+func SetMaxProcsFromCgroup() (int, bool) {
+	current := runtime.GOMAXPROCS(0)
+	if _, exist := os.LookupEnv("GOMAXPROCS"); exist {
+		return current, false
+	}
+	limits, ok := ReadCgroupLimits()
+	if !ok || limits.CPUQuota <= 0 {
+		return current, false
+	}
+	procs := int(math.Floor(limits.CPUQuota))
+	if procs < 1 {
+		procs = 1
+	} else if procs > runtime.NumCPU() {
+		procs = runtime.NumCPU()
+	}
+	if procs == current {
+		return current, false
+	}
+	runtime.GOMAXPROCS(procs)
+	return procs, true
+}
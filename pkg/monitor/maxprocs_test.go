@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"runtime"
+	"testing"
+)
+
+// restoreMaxProcs resets GOMAXPROCS when t ends
+func restoreMaxProcs(t *testing.T) {
+	previous := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() { runtime.GOMAXPROCS(previous) })
+}
+
+func TestSetMaxProcsFromCgroup(t *testing.T) {
+	restoreMaxProcs(t)
+	runtime.GOMAXPROCS(runtime.NumCPU())
+	// 1.5 CPUs are rounded down
+	cgroupFixture(t, map[string]string{"cgroup.controllers": "cpu", "cpu.max": "150000 100000"})
+
+	procs, changed := SetMaxProcsFromCgroup()
+	if procs != 1 || runtime.GOMAXPROCS(0) != 1 {
+		t.Errorf("GOMAXPROCS = %d, want 1", procs)
+	}
+	if changed != (runtime.NumCPU() > 1) {
+		t.Errorf("changed = %v", changed)
+	}
+}
+
+func TestSetMaxProcsFromCgroupAboveCPUs(t *testing.T) {
+	restoreMaxProcs(t)
+	cgroupFixture(t, map[string]string{"cgroup.controllers": "cpu", "cpu.max": "100000000 100000"})
+	if procs, _ := SetMaxProcsFromCgroup(); procs != runtime.NumCPU() {
+		t.Errorf("GOMAXPROCS = %d, want the %d CPUs", procs, runtime.NumCPU())
+	}
+}
+
+func TestSetMaxProcsFromCgroupUnchanged(t *testing.T) {
+	restoreMaxProcs(t)
+	runtime.GOMAXPROCS(2)
+	cgroupFixture(t, map[string]string{"cgroup.controllers": "cpu", "cpu.max": "max 100000"})
+	if procs, changed := SetMaxProcsFromCgroup(); procs != 2 || changed {
+		t.Errorf("without quota: SetMaxProcsFromCgroup() = %d, %v, want 2, false", procs, changed)
+	}
+
+	cgroupFixture(t, map[string]string{"cgroup.controllers": "cpu", "cpu.max": "100000 100000"})
+	t.Setenv("GOMAXPROCS", "2")
+	if procs, changed := SetMaxProcsFromCgroup(); procs != 2 || changed {
+		t.Errorf("with GOMAXPROCS: SetMaxProcsFromCgroup() = %d, %v, want 2, false", procs, changed)
+	}
+}
@@ -0,0 +1,280 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NoLabels is the label set of metrics without labels
+type NoLabels struct{}
+
+// Opts declares a metric. The labels are the string fields of the label set type L,
+// named by their `label` tag or by the field name in snake case:
+//
+//	type OrderLabels struct {
+//		Channel string
+//		Status  string `label:"order_status"`
+//	}
+//	var ordersCreated = monitor.NewCounter[OrderLabels](nil, monitor.Opts{Name: "orders_created_total"})
+//	ordersCreated.Inc(ctx, OrderLabels{Channel: "web", Status: "paid"})
+//
+// Metrics declared with a nil recorder are registered on first use in the Default recorder, then again
+// if the Default recorder changes, e.g. when set by the Fx monitor module
+type Opts struct {
+	Name string
+	Help string
+	// Buckets of histograms, e.g. a preset like DBBuckets. Default is GetHistorgramBuckets
+	Buckets []float64
+	// Native enables the native histogram of histograms
+	Native *NativeHistogramOpts
+	// Guarded are the labels limited to LabelLimit distinct values, all of them if empty.
+	// The declaration panics when one of them is not a label of the set
+	Guarded []string
+}
+
+// Counter is a counter vector whose labels are the fields of L
+type Counter[L any] struct {
+	vec    *boundVec[prometheus.Counter]
+	labels labelSet
+}
+
+// NewCounter registers a counter in the registerer of recorder, the Default recorder if nil.
+// Declaring the same metric again returns the registered one
+func NewCounter[L any](recorder *MonitorRecorder, opts Opts) *Counter[L] {
+	labels := newLabelSet[L]()
+	labels.checkGuarded(opts)
+	return &Counter[L]{labels: labels, vec: bind(recorder, func(recorder *MonitorRecorder) *metrics.LimitedVec[prometheus.Counter] {
+		vec := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        opts.Name,
+			Help:        help(opts),
+			ConstLabels: recorder.constLabels,
+		}, labels.names)
+		return limit[prometheus.Counter](recorder, vec, opts, labels)
+	})}
+}
+
+// Inc adds 1, with the trace_id of ctx as exemplar
+func (c *Counter[L]) Inc(ctx context.Context, labels L) {
+	c.Add(ctx, labels, 1)
+}
+
+// Add adds value, with the trace_id of ctx as exemplar
+func (c *Counter[L]) Add(ctx context.Context, labels L, value float64) {
+	add(ctx, c.vec.get().WithLabelValues(c.labels.values(labels)...), value)
+}
+
+// Gauge is a gauge vector whose labels are the fields of L
+type Gauge[L any] struct {
+	vec    *boundVec[prometheus.Gauge]
+	labels labelSet
+}
+
+// NewGauge registers a gauge in the registerer of recorder, the Default recorder if nil
+func NewGauge[L any](recorder *MonitorRecorder, opts Opts) *Gauge[L] {
+	labels := newLabelSet[L]()
+	labels.checkGuarded(opts)
+	return &Gauge[L]{labels: labels, vec: bind(recorder, func(recorder *MonitorRecorder) *metrics.LimitedVec[prometheus.Gauge] {
+		vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        opts.Name,
+			Help:        help(opts),
+			ConstLabels: recorder.constLabels,
+		}, labels.names)
+		return limit[prometheus.Gauge](recorder, vec, opts, labels)
+	})}
+}
+
+func (g *Gauge[L]) Set(labels L, value float64) {
+	g.vec.get().WithLabelValues(g.labels.values(labels)...).Set(value)
+}
+
+func (g *Gauge[L]) Add(labels L, value float64) {
+	g.vec.get().WithLabelValues(g.labels.values(labels)...).Add(value)
+}
+
+func (g *Gauge[L]) Inc(labels L) {
+	g.Add(labels, 1)
+}
+
+func (g *Gauge[L]) Dec(labels L) {
+	g.Add(labels, -1)
+}
+
+// Histogram is a histogram vector whose labels are the fields of L
+type Histogram[L any] struct {
+	vec    *boundVec[prometheus.Observer]
+	labels labelSet
+}
+
+// NewHistogram registers a histogram in the registerer of recorder, the Default recorder if nil
+func NewHistogram[L any](recorder *MonitorRecorder, opts Opts) *Histogram[L] {
+	labels := newLabelSet[L]()
+	labels.checkGuarded(opts)
+	settings := HistogramSettings{Buckets: opts.Buckets, Native: opts.Native}
+	if len(settings.Buckets) == 0 {
+		settings.Buckets = GetHistorgramBuckets()
+	}
+	return &Histogram[L]{labels: labels, vec: bind(recorder, func(recorder *MonitorRecorder) *metrics.LimitedVec[prometheus.Observer] {
+		histogramOpts := prometheus.HistogramOpts{
+			Name:        opts.Name,
+			Help:        help(opts),
+			ConstLabels: recorder.constLabels,
+		}
+		settings.Apply(&histogramOpts)
+		vec := prometheus.NewHistogramVec(histogramOpts, labels.names)
+		return limit[prometheus.Observer](recorder, vec, opts, labels)
+	})}
+}
+
+// Observe records value, with the trace_id of ctx as exemplar
+func (h *Histogram[L]) Observe(ctx context.Context, labels L, value float64) {
+	observe(ctx, h.vec.get().WithLabelValues(h.labels.values(labels)...), value)
+}
+
+func limit[T any](recorder *MonitorRecorder, vec metrics.CollectorVec[T], opts Opts, labels labelSet) *metrics.LimitedVec[T] {
+	return metrics.RegisterLimited(recorder.registerer, vec, NewLabelLimiter(opts.Name, labels.names, opts.Guarded...))
+}
+
+// boundVec is a vector registered in its recorder, or in the Default recorder when declared with nil
+type boundVec[T any] struct {
+	recorder *MonitorRecorder
+	build    func(recorder *MonitorRecorder) *metrics.LimitedVec[T]
+	cache    atomic.Value // binding[T]
+}
+
+type binding[T any] struct {
+	recorder *MonitorRecorder
+	vec      *metrics.LimitedVec[T]
+}
+
+// bind registers the vector now when recorder is set, on first use otherwise
+func bind[T any](recorder *MonitorRecorder, build func(recorder *MonitorRecorder) *metrics.LimitedVec[T]) *boundVec[T] {
+	v := &boundVec[T]{recorder: recorder, build: build}
+	if recorder != nil {
+		v.get()
+	}
+	return v
+}
+
+// get returns the vector of the recorder, registered again when the Default recorder changes
+func (v *boundVec[T]) get() *metrics.LimitedVec[T] {
+	recorder := v.recorder
+	if recorder == nil {
+		recorder = Default()
+	}
+	if cached, ok := v.cache.Load().(binding[T]); ok && cached.recorder == recorder {
+		return cached.vec
+	}
+	vec := v.build(recorder)
+	v.cache.Store(binding[T]{recorder: recorder, vec: vec})
+	return vec
+}
+
+func help(opts Opts) string {
+	if len(opts.Help) == 0 {
+		return opts.Name
+	}
+	return opts.Help
+}
+
+// labelSet maps the string fields of a label set type to label names
+type labelSet struct {
+	names  []string
+	fields []int
+}
+
+func newLabelSet[L any]() labelSet {
+	var zero L
+	t := reflect.TypeOf(zero)
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("monitor: label set %T is not a struct", zero))
+	}
+	var set labelSet
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.String {
+			panic(fmt.Sprintf("monitor: label %s of %T is not an exported string", field.Name, zero))
+		}
+		name := field.Tag.Get("label")
+		if len(name) == 0 {
+			name = snakeCase(field.Name)
+		}
+		set.names = append(set.names, name)
+		set.fields = append(set.fields, i)
+	}
+	return set
+}
+
+// checkGuarded panics when a label of opts.Guarded is not in the set, the limiter is only created on first use
+func (s labelSet) checkGuarded(opts Opts) {
+	for _, guarded := range opts.Guarded {
+		found := false
+		for _, name := range s.names {
+			found = found || name == guarded
+		}
+		if !found {
+			panic(fmt.Sprintf("monitor: guarded label %s is not a label of %s %v", guarded, opts.Name, s.names))
+		}
+	}
+}
+
+func (s labelSet) values(labels interface{}) []string {
+	v := reflect.ValueOf(labels)
+	values := make([]string, len(s.fields))
+	for i, field := range s.fields {
+		values[i] = v.Field(field).String()
+	}
+	return values
+}
+
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// a new word starts after a lower case letter, or before one in an acronym (HTTPCode)
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// exemplar returns the trace_id of ctx, nil outside of a sampled trace
+func exemplar(ctx context.Context) prometheus.Labels {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{"trace_id": spanContext.TraceID().String()}
+}
+
+func add(ctx context.Context, counter prometheus.Counter, value float64) {
+	if labels := exemplar(ctx); labels != nil {
+		if adder, ok := counter.(prometheus.ExemplarAdder); ok {
+			adder.AddWithExemplar(value, labels)
+			return
+		}
+	}
+	counter.Add(value)
+}
+
+func observe(ctx context.Context, observer prometheus.Observer, value float64) {
+	if labels := exemplar(ctx); labels != nil {
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplarObserver.ObserveWithExemplar(value, labels)
+			return
+		}
+	}
+	observer.Observe(value)
+}
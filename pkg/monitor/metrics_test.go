@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type orderLabels struct {
+	Channel  string
+	HTTPCode string
+	Status   string `label:"order_status"`
+}
+
+func TestLabelSet(t *testing.T) {
+	set := newLabelSet[orderLabels]()
+	if want := []string{"channel", "http_code", "order_status"}; !reflect.DeepEqual(set.names, want) {
+		t.Errorf("names = %v, want %v", set.names, want)
+	}
+	values := set.values(orderLabels{Channel: "web", HTTPCode: "200", Status: "paid"})
+	if want := []string{"web", "200", "paid"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if labels := newLabelSet[NoLabels](); len(labels.names) != 0 {
+		t.Errorf("NoLabels names = %v", labels.names)
+	}
+}
+
+func TestLabelSetInvalid(t *testing.T) {
+	type intLabel struct{ Count int }
+	type unexported struct{ name string }
+	for name, build := range map[string]func(){
+		"not a struct": func() { newLabelSet[string]() },
+		"int field":    func() { newLabelSet[intLabel]() },
+		"unexported":   func() { newLabelSet[unexported]() },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: newLabelSet should panic", name)
+				}
+			}()
+			build()
+		}()
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"Channel":   "channel",
+		"UserID":    "user_id",
+		"HTTPCode":  "http_code",
+		"errorCode": "error_code",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// setTestDefault makes a recorder of a new registry the Default one until the end of the test
+func setTestDefault(t *testing.T) *prometheus.Registry {
+	t.Helper()
+	mu.Lock()
+	previous := GlobalRecorder
+	mu.Unlock()
+	t.Cleanup(func() {
+		SetDefault(previous)
+	})
+	registry := prometheus.NewRegistry()
+	SetDefault(NewMonitorRecorder(registry))
+	return registry
+}
+
+func gatherMetric(t *testing.T, gatherer prometheus.Gatherer, name string) *dto.Metric {
+	t.Helper()
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == name && len(family.Metric) > 0 {
+			return family.Metric[0]
+		}
+	}
+	return nil
+}
+
+func TestNilRecorderFollowsDefault(t *testing.T) {
+	first := setTestDefault(t)
+	counter := NewCounter[orderLabels](nil, Opts{Name: "test_lazy_orders_total"})
+	if gatherMetric(t, first, "test_lazy_orders_total") != nil {
+		t.Error("the counter should be registered on first use")
+	}
+	counter.Inc(context.Background(), orderLabels{Channel: "web"})
+	if metric := gatherMetric(t, first, "test_lazy_orders_total"); metric == nil || metric.Counter.GetValue() != 1 {
+		t.Errorf("first registry: %v", metric)
+	}
+
+	second := setTestDefault(t)
+	counter.Inc(context.Background(), orderLabels{Channel: "web"})
+	if metric := gatherMetric(t, second, "test_lazy_orders_total"); metric == nil || metric.Counter.GetValue() != 1 {
+		t.Errorf("second registry: %v", metric)
+	}
+}
+
+func sampledContext() (context.Context, string) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), spanContext), spanContext.TraceID().String()
+}
+
+func exemplarTraceID(exemplar *dto.Exemplar) string {
+	for _, label := range exemplar.GetLabel() {
+		if label.GetName() == "trace_id" {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestExemplars(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	recorder := NewMonitorRecorder(registry)
+	counter := NewCounter[NoLabels](recorder, Opts{Name: "test_exemplar_total"})
+	histogram := NewHistogram[NoLabels](recorder, Opts{Name: "test_exemplar_seconds", Buckets: []float64{1}})
+	ctx, traceID := sampledContext()
+
+	counter.Inc(ctx, NoLabels{})
+	histogram.Observe(ctx, NoLabels{}, 0.5)
+	if got := exemplarTraceID(gatherMetric(t, registry, "test_exemplar_total").Counter.GetExemplar()); got != traceID {
+		t.Errorf("counter exemplar = %q, want %q", got, traceID)
+	}
+	if got := exemplarTraceID(gatherMetric(t, registry, "test_exemplar_seconds").Histogram.Bucket[0].GetExemplar()); got != traceID {
+		t.Errorf("histogram exemplar = %q, want %q", got, traceID)
+	}
+
+	// outside of a sampled trace
+	unsampled := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx).WithTraceFlags(0))
+	counter.Inc(unsampled, NoLabels{})
+	metric := gatherMetric(t, registry, "test_exemplar_total")
+	if metric.Counter.GetValue() != 2 || exemplarTraceID(metric.Counter.GetExemplar()) != traceID {
+		t.Errorf("unsampled increment should keep the exemplar: %v", metric)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	recorder := NewMonitorRecorder(registry)
+	var observed []string
+	recorder.AddObserver(func(name, metricType, method string, duration time.Duration, err error) {
+		observed = append(observed, name+" "+metricType+" "+method)
+		if duration <= 0 {
+			t.Errorf("duration = %v", duration)
+		}
+	})
+	ctx, traceID := sampledContext()
+
+	recorder.Timer(ctx, "user-service", "grpc", "GetUser")(nil)
+	recorder.Timer(ctx, "user-service", "grpc", "GetUser")(errors.New("boom"))
+
+	duration := gatherMetric(t, registry, "invocations_seconds")
+	if duration == nil || duration.Histogram.GetSampleCount() != 2 {
+		t.Fatalf("invocations_seconds = %v", duration)
+	}
+	var exemplars int
+	for _, bucket := range duration.Histogram.Bucket {
+		if exemplarTraceID(bucket.GetExemplar()) == traceID {
+			exemplars++
+		}
+	}
+	if exemplars == 0 {
+		t.Error("invocations_seconds has no exemplar")
+	}
+	errorsMetric := gatherMetric(t, registry, "invocations_errors_total")
+	if errorsMetric == nil || errorsMetric.Counter.GetValue() != 1 {
+		t.Errorf("invocations_errors_total = %v", errorsMetric)
+	}
+	if want := []string{"user-service grpc GetUser", "user-service grpc GetUser"}; !reflect.DeepEqual(observed, want) {
+		t.Errorf("observed = %v, want %v", observed, want)
+	}
+}
+
+func TestGuardedLabels(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewCounter should panic on an unknown guarded label")
+		}
+	}()
+	// the label of Status is order_status, checked even though a nil recorder registers on first use
+	NewCounter[orderLabels](nil, Opts{Name: "test_guarded_total", Guarded: []string{"status"}})
+}
@@ -1,47 +1,93 @@
 package monitor
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/nmtri1912/go-common/pkg/metrics"
 	"github.com/nmtri1912/go-common/utils/errorutils"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/spf13/viper"
 )
 
 var mu = sync.Mutex{}
 
+// MonitorRecorder records the invocations_seconds and invocations_errors_total metrics,
+// and registers the metrics declared with NewCounter, NewGauge and NewHistogram
 type MonitorRecorder struct {
-	invocationErrorCounter *prometheus.CounterVec
+	// error_code is limited to LabelLimit values, the reasons of the errors are free-form
+	invocationErrorCounter *metrics.LimitedVec[prometheus.Counter]
 	durationBuckets        *prometheus.HistogramVec
+
+	registerer  prometheus.Registerer
+	constLabels prometheus.Labels
+
+	observersMutex sync.RWMutex
+	observers      []InvocationObserver
 }
 
+// InvocationObserver receives the invocations recorded by Timer and RecordMetrics
+type InvocationObserver func(name, metricType, method string, duration time.Duration, err error)
+
+// GlobalRecorder is the recorder of the package functions, use Default and SetDefault
 var GlobalRecorder *MonitorRecorder = nil
 
+// InitMonitorMetrics creates GlobalRecorder, registered in prometheus.DefaultRegisterer
 func InitMonitorMetrics() {
+	InitMonitorMetricsWith(prometheus.DefaultRegisterer)
+}
+
+// InitMonitorMetricsWith creates GlobalRecorder, registered in registerer
+func InitMonitorMetricsWith(registerer prometheus.Registerer) {
 	mu.Lock()
 	defer mu.Unlock()
 	if GlobalRecorder != nil {
 		return
 	}
 	logger.L().Info("Init monitor metrics...")
-	GlobalRecorder = NewMonitorRecorder()
+	GlobalRecorder = NewMonitorRecorder(registerer)
 	logger.L().Info("Inited monitor metrics")
 }
 
-func NewMonitorRecorder() *MonitorRecorder {
+// Default returns GlobalRecorder, created in prometheus.DefaultRegisterer on first use if not set
+func Default() *MonitorRecorder {
+	mu.Lock()
+	recorder := GlobalRecorder
+	mu.Unlock()
+	if recorder == nil {
+		InitMonitorMetrics()
+		mu.Lock()
+		recorder = GlobalRecorder
+		mu.Unlock()
+	}
+	return recorder
+}
+
+// SetDefault replaces GlobalRecorder, e.g. by the recorder provided by monitor.Module
+func SetDefault(recorder *MonitorRecorder) {
+	mu.Lock()
+	defer mu.Unlock()
+	GlobalRecorder = recorder
+}
+
+// NewMonitorRecorder registers the collectors in registerer, prometheus.DefaultRegisterer if nil.
+// Recorders sharing a registerer share their collectors
+func NewMonitorRecorder(registerer prometheus.Registerer) *MonitorRecorder {
 	constLabels := prometheus.Labels{
 		"application": viper.GetString("service.name"),
 	}
 
 	labels := []string{"name", "type", "method"}
-	durationBuckets := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	durationOpts := prometheus.HistogramOpts{
 		Name:        "invocations_seconds",
 		Help:        "Duration buckets",
-		Buckets:     GetHistorgramBuckets(),
 		ConstLabels: constLabels,
-	}, labels)
+	}
+	LoadHistogramSettings("invocations", GetHistorgramBuckets()).Apply(&durationOpts)
+	durationBuckets := prometheus.NewHistogramVec(durationOpts, labels)
 
 	labels = []string{"name", "type", "method", "error_code"}
 	invocationErrorCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -57,27 +103,69 @@ func NewMonitorRecorder() *MonitorRecorder {
 		startTime:          time.Now(),
 	}
 
-	prometheus.MustRegister(invocationErrorCounter, durationBuckets, systemMetrics)
+	errorCodeLimiter := NewLabelLimiter("invocations_errors_total", labels, "error_code")
+
+	metrics.Register(registerer, systemMetrics)
+	metrics.Register(registerer, NewRuntimeMetrics(constLabels))
+	// open fds, RSS and CPU seconds from /proc, already in the default registry and in metrics.NewRegistry
+	metrics.Register(registerer, collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 	return &MonitorRecorder{
-		invocationErrorCounter: invocationErrorCounter,
-		durationBuckets:        durationBuckets,
+		invocationErrorCounter: metrics.RegisterLimited[prometheus.Counter](registerer, invocationErrorCounter, errorCodeLimiter),
+		durationBuckets:        metrics.Register(registerer, durationBuckets).(*prometheus.HistogramVec),
+		registerer:             metrics.Registerer(registerer),
+		constLabels:            constLabels,
 	}
 }
 
-func RecordMetrics(service, metricType, method string, start time.Time, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			logger.L().Warn("recovered in RecordMetrics")
-		}
-	}()
-
-	duration := time.Since(start).Seconds()
-	GlobalRecorder.durationBuckets.WithLabelValues(service, metricType, method).Observe(duration)
+// Timer starts timing an invocation, the returned function records its duration and error.
+// The duration carries the trace_id of ctx as exemplar
+//
+//	stop := recorder.Timer(ctx, "user-service", "grpc", "GetUser")
+//	defer func() { stop(err) }()
+func (r *MonitorRecorder) Timer(ctx context.Context, name, metricType, method string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		r.record(ctx, name, metricType, method, time.Since(start), err)
+	}
+}
+
+func (r *MonitorRecorder) record(ctx context.Context, name, metricType, method string, duration time.Duration, err error) {
+	observe(ctx, r.durationBuckets.WithLabelValues(name, metricType, method), duration.Seconds())
 	if err != nil {
 		_, errorReason, _ := errorutils.ExtractReasonAndDomainFromError(err, "")
-		GlobalRecorder.invocationErrorCounter.
-			WithLabelValues(service, metricType, method, errorReason).
-			Inc()
+		add(ctx, r.invocationErrorCounter.WithLabelValues(name, metricType, method, errorReason), 1)
+	}
+	r.observersMutex.RLock()
+	defer r.observersMutex.RUnlock()
+	for _, observer := range r.observers {
+		observer(name, metricType, method, duration, err)
 	}
+}
 
+// AddObserver registers observer for the following invocations, e.g. to track SLOs
+func (r *MonitorRecorder) AddObserver(observer InvocationObserver) {
+	r.observersMutex.Lock()
+	defer r.observersMutex.Unlock()
+	r.observers = append(r.observers, observer)
+}
+
+// ConstLabels returns the labels of every metric of the recorder, e.g. application
+func (r *MonitorRecorder) ConstLabels() prometheus.Labels {
+	labels := make(prometheus.Labels, len(r.constLabels))
+	for name, value := range r.constLabels {
+		labels[name] = value
+	}
+	return labels
+}
+
+// Timer is MonitorRecorder.Timer of the Default recorder
+func Timer(ctx context.Context, name, metricType, method string) func(err error) {
+	return Default().Timer(ctx, name, metricType, method)
+}
+
+// RecordMetrics records an invocation started at start with the Default recorder.
+//
+// Deprecated: use Timer
+func RecordMetrics(service, metricType, method string, start time.Time, err error) {
+	Default().record(context.Background(), service, metricType, method, time.Since(start), err)
 }
@@ -0,0 +1,208 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/procfs"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const otlpScope = "github.com/nmtri1912/go-common/pkg/monitor"
+
+// processStartTime is the start time of the cumulative metrics, read from /proc on Linux,
+// the package initialization otherwise
+var processStartTime = readProcessStartTime()
+
+func readProcessStartTime() time.Time {
+	if proc, err := procfs.Self(); err == nil {
+		if stat, err := proc.Stat(); err == nil {
+			if start, err := stat.StartTime(); err == nil {
+				return time.Unix(0, int64(start*float64(time.Second)))
+			}
+		}
+	}
+	return time.Now()
+}
+
+// OTLPPusher sends the metrics of a gatherer to an OpenTelemetry collector with OTLP/HTTP.
+// Counters become monotonic cumulative sums, gauges and untyped metrics gauges, histograms and summaries keep their type
+type OTLPPusher struct {
+	url      string
+	headers  map[string]string
+	gatherer prometheus.Gatherer
+	resource *resourcepb.Resource
+	client   *http.Client
+}
+
+// NewOTLPPusher posts to url, e.g. http://otel-collector:4318/v1/metrics. service is the service.name resource attribute
+func NewOTLPPusher(url, service string, gatherer prometheus.Gatherer, headers map[string]string) *OTLPPusher {
+	attributes := []*commonpb.KeyValue{stringAttribute("service.name", service)}
+	if hostname, err := os.Hostname(); err == nil {
+		attributes = append(attributes, stringAttribute("host.name", hostname))
+	}
+	return &OTLPPusher{
+		url:      url,
+		headers:  headers,
+		gatherer: gatherer,
+		resource: &resourcepb.Resource{Attributes: attributes},
+		client:   http.DefaultClient,
+	}
+}
+
+// Push sends the gathered metrics. When some collectors fail, the other metrics are sent and the error is returned
+func (p *OTLPPusher) Push(ctx context.Context) error {
+	families, gatherErr := p.gatherer.Gather()
+	if gatherErr != nil && len(families) == 0 {
+		return gatherErr
+	}
+	body, err := proto.Marshal(p.toOTLP(families, time.Now()))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	for key, value := range p.headers {
+		request.Header.Set(key, value)
+	}
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%s responded %s", p.url, response.Status)
+	}
+	if gatherErr != nil {
+		return fmt.Errorf("metrics pushed without the failed collectors: %w", gatherErr)
+	}
+	return nil
+}
+
+func (p *OTLPPusher) toOTLP(families []*dto.MetricFamily, now time.Time) *collectormetrics.ExportMetricsServiceRequest {
+	start, timestamp := uint64(processStartTime.UnixNano()), uint64(now.UnixNano())
+	metrics := make([]*metricspb.Metric, 0, len(families))
+	for _, family := range families {
+		metric := &metricspb.Metric{Name: family.GetName(), Description: family.GetHelp()}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			sum := &metricspb.Sum{AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, IsMonotonic: true}
+			for _, m := range family.GetMetric() {
+				sum.DataPoints = append(sum.DataPoints, numberPoint(m, m.GetCounter().GetValue(), start, timestamp))
+			}
+			metric.Data = &metricspb.Metric_Sum{Sum: sum}
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			gauge := &metricspb.Gauge{}
+			for _, m := range family.GetMetric() {
+				value := m.GetGauge().GetValue()
+				if family.GetType() == dto.MetricType_UNTYPED {
+					value = m.GetUntyped().GetValue()
+				}
+				gauge.DataPoints = append(gauge.DataPoints, numberPoint(m, value, start, timestamp))
+			}
+			metric.Data = &metricspb.Metric_Gauge{Gauge: gauge}
+		case dto.MetricType_HISTOGRAM:
+			histogram := &metricspb.Histogram{AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE}
+			for _, m := range family.GetMetric() {
+				histogram.DataPoints = append(histogram.DataPoints, histogramPoint(m, start, timestamp))
+			}
+			metric.Data = &metricspb.Metric_Histogram{Histogram: histogram}
+		case dto.MetricType_SUMMARY:
+			summary := &metricspb.Summary{}
+			for _, m := range family.GetMetric() {
+				summary.DataPoints = append(summary.DataPoints, summaryPoint(m, start, timestamp))
+			}
+			metric.Data = &metricspb.Metric_Summary{Summary: summary}
+		default:
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	return &collectormetrics.ExportMetricsServiceRequest{ResourceMetrics: []*metricspb.ResourceMetrics{{
+		Resource: p.resource,
+		ScopeMetrics: []*metricspb.ScopeMetrics{{
+			Scope:   &commonpb.InstrumentationScope{Name: otlpScope},
+			Metrics: metrics,
+		}},
+	}}}
+}
+
+func numberPoint(m *dto.Metric, value float64, start, timestamp uint64) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		Attributes:        attributes(m),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      timestamp,
+		Value:             &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+// histogramPoint converts the cumulative buckets of prometheus to the per bucket counts of OTLP
+func histogramPoint(m *dto.Metric, start, timestamp uint64) *metricspb.HistogramDataPoint {
+	histogram := m.GetHistogram()
+	sum := histogram.GetSampleSum()
+	point := &metricspb.HistogramDataPoint{
+		Attributes:        attributes(m),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      timestamp,
+		Count:             histogram.GetSampleCount(),
+		Sum:               &sum,
+	}
+	var previous uint64
+	for _, bucket := range histogram.GetBucket() {
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+		point.ExplicitBounds = append(point.ExplicitBounds, bucket.GetUpperBound())
+		point.BucketCounts = append(point.BucketCounts, bucket.GetCumulativeCount()-previous)
+		previous = bucket.GetCumulativeCount()
+	}
+	// the last bucket counts the values above the last bound
+	point.BucketCounts = append(point.BucketCounts, histogram.GetSampleCount()-previous)
+	return point
+}
+
+func summaryPoint(m *dto.Metric, start, timestamp uint64) *metricspb.SummaryDataPoint {
+	summary := m.GetSummary()
+	point := &metricspb.SummaryDataPoint{
+		Attributes:        attributes(m),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      timestamp,
+		Count:             summary.GetSampleCount(),
+		Sum:               summary.GetSampleSum(),
+	}
+	for _, quantile := range summary.GetQuantile() {
+		point.QuantileValues = append(point.QuantileValues, &metricspb.SummaryDataPoint_ValueAtQuantile{
+			Quantile: quantile.GetQuantile(),
+			Value:    quantile.GetValue(),
+		})
+	}
+	return point
+}
+
+func attributes(m *dto.Metric) []*commonpb.KeyValue {
+	attributes := make([]*commonpb.KeyValue, 0, len(m.GetLabel()))
+	for _, label := range m.GetLabel() {
+		attributes = append(attributes, stringAttribute(label.GetName(), label.GetValue()))
+	}
+	return attributes
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
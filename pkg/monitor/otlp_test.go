@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpCollector decodes the requests posted to its URL
+func otlpCollector(t *testing.T) (string, <-chan *collectormetrics.ExportMetricsServiceRequest) {
+	requests := make(chan *collectormetrics.ExportMetricsServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request := &collectormetrics.ExportMetricsServiceRequest{}
+		if err := proto.Unmarshal(body, request); err != nil {
+			t.Errorf("invalid OTLP request: %v", err)
+		}
+		requests <- request
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, requests
+}
+
+func TestOTLPPush(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_otlp_total"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_otlp_seconds", Buckets: []float64{1, 2}})
+	registry.MustRegister(counter, histogram)
+	counter.Add(3)
+	for _, value := range []float64{0.5, 1.5, 1.5, 5} {
+		histogram.Observe(value)
+	}
+
+	url, requests := otlpCollector(t)
+	if err := NewOTLPPusher(url, "orders", registry, nil).Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	metrics := (<-requests).ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 2 {
+		t.Fatalf("pushed %d metrics, want 2", len(metrics))
+	}
+	sum := metrics[1].GetSum().DataPoints[0]
+	if metrics[1].Name != "test_otlp_total" || sum.GetAsDouble() != 3 {
+		t.Errorf("counter = %v", metrics[1])
+	}
+	if start := time.Unix(0, int64(sum.StartTimeUnixNano)); !start.Equal(processStartTime) || !start.Before(time.Now()) {
+		t.Errorf("start time = %v, want the process start time %v", start, processStartTime)
+	}
+	point := metrics[0].GetHistogram().DataPoints[0]
+	if !reflect.DeepEqual(point.ExplicitBounds, []float64{1, 2}) || !reflect.DeepEqual(point.BucketCounts, []uint64{1, 2, 1}) {
+		t.Errorf("histogram bounds %v, counts %v", point.ExplicitBounds, point.BucketCounts)
+	}
+}
+
+func TestOTLPPushPartialGather(t *testing.T) {
+	gatherErr := errors.New("collector failed")
+	value := 1.0
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return []*dto.MetricFamily{{
+			Name:   proto.String("test_otlp_gauge"),
+			Type:   dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: &value}}},
+		}}, gatherErr
+	})
+
+	url, requests := otlpCollector(t)
+	err := NewOTLPPusher(url, "orders", gatherer, nil).Push(context.Background())
+	if !errors.Is(err, gatherErr) {
+		t.Errorf("Push() = %v, want the gather error", err)
+	}
+	select {
+	case request := <-requests:
+		if name := request.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Name; name != "test_otlp_gauge" {
+			t.Errorf("pushed %s", name)
+		}
+	default:
+		t.Error("the gathered metrics should be pushed")
+	}
+}
@@ -0,0 +1,155 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Types of monitor.push.type
+const (
+	PushPushgateway = "pushgateway"
+	PushOTLP        = "otlp"
+)
+
+const defaultPushInterval = 15 * time.Second
+
+// Pusher sends the metrics of a gatherer, for jobs and services without /metrics
+type Pusher interface {
+	Push(ctx context.Context) error
+}
+
+// PushgatewayPusher replaces the metrics of the job and grouping labels on a Prometheus Pushgateway
+type PushgatewayPusher struct {
+	pusher *push.Pusher
+}
+
+// NewPushgatewayPusher pushes to the group of job and grouping, the instance label is the hostname unless given in grouping
+func NewPushgatewayPusher(url, job string, gatherer prometheus.Gatherer, grouping map[string]string) *PushgatewayPusher {
+	pusher := push.New(url, job).Gatherer(gatherer)
+	if _, exist := grouping["instance"]; !exist {
+		if hostname, err := os.Hostname(); err == nil {
+			pusher = pusher.Grouping("instance", hostname)
+		}
+	}
+	for name, value := range grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	return &PushgatewayPusher{pusher: pusher}
+}
+
+func (p *PushgatewayPusher) Push(ctx context.Context) error {
+	return p.pusher.PushContext(ctx)
+}
+
+// PeriodicPusher pushes in background, then a last time on Stop
+type PeriodicPusher struct {
+	pusher   Pusher
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// StartPush pushes every interval, only on Stop when interval <= 0
+func StartPush(pusher Pusher, interval time.Duration) *PeriodicPusher {
+	p := &PeriodicPusher{
+		pusher:   pusher,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *PeriodicPusher) run() {
+	defer close(p.done)
+	if p.interval <= 0 {
+		<-p.stop
+		return
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+			if err := p.pusher.Push(ctx); err != nil {
+				logger.L().Warn("Push metrics has error: ", zap.Error(err))
+			}
+			cancel()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Stop ends the periodic pushes and pushes the final values, until ctx is done
+func (p *PeriodicPusher) Stop(ctx context.Context) error {
+	p.once.Do(func() {
+		close(p.stop)
+	})
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.pusher.Push(ctx)
+}
+
+// NewPusherFromConfig creates the pusher configured by monitor.push.*, nil when monitor.push.type is empty.
+// The interval is the period of the pushes
+func NewPusherFromConfig(gatherer prometheus.Gatherer) (Pusher, time.Duration, error) {
+	pushType := viper.GetString("monitor.push.type")
+	if len(pushType) == 0 {
+		return nil, 0, nil
+	}
+	url := viper.GetString("monitor.push.url")
+	if len(url) == 0 {
+		return nil, 0, fmt.Errorf("monitor.push.url is required")
+	}
+	job := viper.GetString("monitor.push.job")
+	if len(job) == 0 {
+		job = viper.GetString("service.name")
+	}
+	interval := defaultPushInterval
+	if viper.IsSet("monitor.push.interval-sec") {
+		interval = time.Duration(viper.GetInt("monitor.push.interval-sec")) * time.Second
+	}
+	headers := viper.GetStringMapString("monitor.push.headers")
+	switch strings.ToLower(pushType) {
+	case PushPushgateway:
+		pusher := NewPushgatewayPusher(url, job, gatherer, viper.GetStringMapString("monitor.push.grouping"))
+		if len(headers) > 0 {
+			pusher.pusher = pusher.pusher.Client(&headerClient{headers: headers})
+		}
+		return pusher, interval, nil
+	case PushOTLP:
+		return NewOTLPPusher(url, job, gatherer, headers), interval, nil
+	default:
+		return nil, 0, fmt.Errorf("unknown monitor.push.type %q", pushType)
+	}
+}
+
+// headerClient adds headers to the requests, e.g. for authentication
+type headerClient struct {
+	headers map[string]string
+}
+
+func (c *headerClient) Do(request *http.Request) (*http.Response, error) {
+	for key, value := range c.headers {
+		request.Header.Set(key, value)
+	}
+	return http.DefaultClient.Do(request)
+}
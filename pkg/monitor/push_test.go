@@ -0,0 +1,158 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+)
+
+type pushRequest struct {
+	method        string
+	path          string
+	authorization string
+}
+
+// pushgateway records the requests sent to its URL
+func pushgateway(t *testing.T) (string, <-chan pushRequest) {
+	requests := make(chan pushRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- pushRequest{method: r.Method, path: r.URL.Path, authorization: r.Header.Get("Authorization")}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, requests
+}
+
+func testGatherer() prometheus.Gatherer {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_push_total"})
+	registry.MustRegister(counter)
+	counter.Inc()
+	return registry
+}
+
+func TestPushgatewayPusherGrouping(t *testing.T) {
+	url, requests := pushgateway(t)
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	if err := NewPushgatewayPusher(url, "import", testGatherer(), nil).Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if request := <-requests; request.method != http.MethodPut || request.path != "/metrics/job/import/instance/"+hostname {
+		t.Errorf("pushed with %s %s, want the hostname as instance", request.method, request.path)
+	}
+
+	grouping := map[string]string{"instance": "worker-1"}
+	if err := NewPushgatewayPusher(url, "import", testGatherer(), grouping).Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if request := <-requests; request.path != "/metrics/job/import/instance/worker-1" {
+		t.Errorf("pushed to %s, want the configured instance", request.path)
+	}
+}
+
+// countingPusher records each push on pushes
+type countingPusher struct {
+	pushes chan struct{}
+}
+
+func (p *countingPusher) Push(ctx context.Context) error {
+	p.pushes <- struct{}{}
+	return nil
+}
+
+func TestPeriodicPusher(t *testing.T) {
+	pusher := &countingPusher{pushes: make(chan struct{}, 100)}
+	periodic := StartPush(pusher, 10*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-pusher.pushes:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no periodic push")
+		}
+	}
+	if err := periodic.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the final push is done by Stop
+	if len(pusher.pushes) == 0 {
+		t.Error("no final push")
+	}
+}
+
+func TestPeriodicPusherStopBeforeInterval(t *testing.T) {
+	for _, interval := range []time.Duration{time.Hour, 0} {
+		pusher := &countingPusher{pushes: make(chan struct{}, 10)}
+		periodic := StartPush(pusher, interval)
+		if err := periodic.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(pusher.pushes); got != 1 {
+			t.Errorf("interval %v: pushed %d times, want the final push", interval, got)
+		}
+	}
+}
+
+func setPushConfig(t *testing.T, config map[string]interface{}) {
+	t.Helper()
+	for key, value := range config {
+		viper.Set(key, value)
+	}
+	t.Cleanup(func() {
+		for key := range config {
+			viper.Set(key, nil)
+		}
+	})
+}
+
+func TestNewPusherFromConfig(t *testing.T) {
+	url, requests := pushgateway(t)
+	setPushConfig(t, map[string]interface{}{
+		"monitor.push.type":         "Pushgateway",
+		"monitor.push.url":          url,
+		"monitor.push.job":          "import",
+		"monitor.push.interval-sec": 30,
+		"monitor.push.grouping":     map[string]string{"instance": "worker-1"},
+		"monitor.push.headers":      map[string]string{"Authorization": "Bearer xyz"},
+	})
+	pusher, interval, err := NewPusherFromConfig(testGatherer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interval != 30*time.Second {
+		t.Errorf("interval = %v, want 30s", interval)
+	}
+	if err := pusher.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	request := <-requests
+	if request.path != "/metrics/job/import/instance/worker-1" || request.authorization != "Bearer xyz" {
+		t.Errorf("pushed to %s with authorization %q", request.path, request.authorization)
+	}
+}
+
+func TestNewPusherFromConfigInvalid(t *testing.T) {
+	pusher, _, err := NewPusherFromConfig(testGatherer())
+	if pusher != nil || err != nil {
+		t.Errorf("NewPusherFromConfig() without type = %v, %v, want nil", pusher, err)
+	}
+
+	setPushConfig(t, map[string]interface{}{"monitor.push.type": "pushgateway"})
+	if _, _, err := NewPusherFromConfig(testGatherer()); err == nil {
+		t.Error("NewPusherFromConfig() without url should fail")
+	}
+
+	setPushConfig(t, map[string]interface{}{"monitor.push.type": "statsd", "monitor.push.url": "http://localhost:8125"})
+	if _, _, err := NewPusherFromConfig(testGatherer()); err == nil {
+		t.Error("NewPusherFromConfig() with an unknown type should fail")
+	}
+}
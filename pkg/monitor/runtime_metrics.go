@@ -0,0 +1,166 @@
+package monitor
+
+import (
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var runtimeQuantiles = []float64{0.5, 0.9, 0.99, 1}
+
+// RuntimeMetrics exports the runtime/metrics of the Go runtime and the cgroup limits of the process
+type RuntimeMetrics struct {
+	gcPauseDesc          *prometheus.Desc
+	schedLatencyDesc     *prometheus.Desc
+	heapInUseDesc        *prometheus.Desc
+	heapAllocatedDesc    *prometheus.Desc
+	maxProcsDesc         *prometheus.Desc
+	cpuQuotaDesc         *prometheus.Desc
+	memoryLimitDesc      *prometheus.Desc
+	cpuPeriodsDesc       *prometheus.Desc
+	cpuThrottledDesc     *prometheus.Desc
+	cpuThrottledTimeDesc *prometheus.Desc
+
+	samples []metrics.Sample
+}
+
+// runtime/metrics names, the first supported one of each list is read
+var (
+	gcPauseMetrics      = []string{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"}
+	schedLatencyMetrics = []string{"/sched/latencies:seconds"}
+	heapObjectsMetrics  = []string{"/memory/classes/heap/objects:bytes"}
+	heapUnusedMetrics   = []string{"/memory/classes/heap/unused:bytes"}
+	heapAllocsMetrics   = []string{"/gc/heap/allocs:bytes"}
+)
+
+func NewRuntimeMetrics(constLabels prometheus.Labels) *RuntimeMetrics {
+	supported := make(map[string]bool)
+	for _, description := range metrics.All() {
+		supported[description.Name] = true
+	}
+	var samples []metrics.Sample
+	for _, names := range [][]string{gcPauseMetrics, schedLatencyMetrics, heapObjectsMetrics, heapUnusedMetrics, heapAllocsMetrics} {
+		sample := metrics.Sample{}
+		for _, name := range names {
+			if supported[name] {
+				sample.Name = name
+				break
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return &RuntimeMetrics{
+		gcPauseDesc:          prometheus.NewDesc("runtime_gc_pause_seconds", "Quantiles of the GC stop-the-world pauses since start", []string{"quantile"}, constLabels),
+		schedLatencyDesc:     prometheus.NewDesc("runtime_sched_latency_seconds", "Quantiles of the time goroutines waited to run since start", []string{"quantile"}, constLabels),
+		heapInUseDesc:        prometheus.NewDesc("runtime_heap_inuse_bytes", "Heap memory occupied by objects and fragmentation", nil, constLabels),
+		heapAllocatedDesc:    prometheus.NewDesc("runtime_heap_allocated_bytes_total", "Bytes allocated on the heap, its rate is the allocation rate", nil, constLabels),
+		maxProcsDesc:         prometheus.NewDesc("runtime_gomaxprocs", "GOMAXPROCS", nil, constLabels),
+		cpuQuotaDesc:         prometheus.NewDesc("cgroup_cpu_quota_cores", "CPU quota of the cgroup in cores", nil, constLabels),
+		memoryLimitDesc:      prometheus.NewDesc("cgroup_memory_limit_bytes", "Memory limit of the cgroup", nil, constLabels),
+		cpuPeriodsDesc:       prometheus.NewDesc("cgroup_cpu_periods_total", "CPU scheduling periods of the cgroup", nil, constLabels),
+		cpuThrottledDesc:     prometheus.NewDesc("cgroup_cpu_throttled_periods_total", "CPU scheduling periods in which the cgroup was throttled", nil, constLabels),
+		cpuThrottledTimeDesc: prometheus.NewDesc("cgroup_cpu_throttled_seconds_total", "Time the cgroup was throttled", nil, constLabels),
+		samples:              samples,
+	}
+}
+
+func (m *RuntimeMetrics) Describe(c chan<- *prometheus.Desc) {
+	c <- m.gcPauseDesc
+	c <- m.schedLatencyDesc
+	c <- m.heapInUseDesc
+	c <- m.heapAllocatedDesc
+	c <- m.maxProcsDesc
+	c <- m.cpuQuotaDesc
+	c <- m.memoryLimitDesc
+	c <- m.cpuPeriodsDesc
+	c <- m.cpuThrottledDesc
+	c <- m.cpuThrottledTimeDesc
+}
+
+func (m *RuntimeMetrics) Collect(c chan<- prometheus.Metric) {
+	// samples are read into a copy, Collect may be called concurrently
+	samples := make([]metrics.Sample, 0, len(m.samples))
+	for _, sample := range m.samples {
+		if len(sample.Name) > 0 {
+			samples = append(samples, metrics.Sample{Name: sample.Name})
+		}
+	}
+	metrics.Read(samples)
+	values := make(map[string]metrics.Value, len(samples))
+	for _, sample := range samples {
+		values[sample.Name] = sample.Value
+	}
+	value := func(names []string) metrics.Value {
+		for _, name := range names {
+			if v, ok := values[name]; ok {
+				return v
+			}
+		}
+		return metrics.Value{}
+	}
+	histogram := func(names []string) *metrics.Float64Histogram {
+		if v := value(names); v.Kind() == metrics.KindFloat64Histogram {
+			return v.Float64Histogram()
+		}
+		return nil
+	}
+
+	collectQuantiles(c, m.gcPauseDesc, histogram(gcPauseMetrics))
+	collectQuantiles(c, m.schedLatencyDesc, histogram(schedLatencyMetrics))
+	heapObjects, heapUnused := value(heapObjectsMetrics), value(heapUnusedMetrics)
+	if heapObjects.Kind() == metrics.KindUint64 && heapUnused.Kind() == metrics.KindUint64 {
+		c <- prometheus.MustNewConstMetric(m.heapInUseDesc, prometheus.GaugeValue, float64(heapObjects.Uint64()+heapUnused.Uint64()))
+	}
+	if allocated := value(heapAllocsMetrics); allocated.Kind() == metrics.KindUint64 {
+		c <- prometheus.MustNewConstMetric(m.heapAllocatedDesc, prometheus.CounterValue, float64(allocated.Uint64()))
+	}
+	c <- prometheus.MustNewConstMetric(m.maxProcsDesc, prometheus.GaugeValue, float64(runtime.GOMAXPROCS(0)))
+
+	limits, ok := ReadCgroupLimits()
+	if !ok {
+		return
+	}
+	if limits.CPUQuota > 0 {
+		c <- prometheus.MustNewConstMetric(m.cpuQuotaDesc, prometheus.GaugeValue, limits.CPUQuota)
+	}
+	if limits.MemoryLimit > 0 {
+		c <- prometheus.MustNewConstMetric(m.memoryLimitDesc, prometheus.GaugeValue, float64(limits.MemoryLimit))
+	}
+	c <- prometheus.MustNewConstMetric(m.cpuPeriodsDesc, prometheus.CounterValue, float64(limits.Periods))
+	c <- prometheus.MustNewConstMetric(m.cpuThrottledDesc, prometheus.CounterValue, float64(limits.ThrottledPeriods))
+	c <- prometheus.MustNewConstMetric(m.cpuThrottledTimeDesc, prometheus.CounterValue, limits.ThrottledSeconds)
+}
+
+// collectQuantiles exports the quantiles of a runtime histogram, as the upper bound of their bucket
+func collectQuantiles(c chan<- prometheus.Metric, desc *prometheus.Desc, histogram *metrics.Float64Histogram) {
+	if histogram == nil {
+		return
+	}
+	var total uint64
+	for _, count := range histogram.Counts {
+		total += count
+	}
+	if total == 0 {
+		return
+	}
+	for _, quantile := range runtimeQuantiles {
+		rank := uint64(math.Ceil(quantile * float64(total)))
+		var cumulative uint64
+		for i, count := range histogram.Counts {
+			cumulative += count
+			if cumulative < rank {
+				continue
+			}
+			// Buckets has one more boundary than Counts, the last ones may be infinite
+			bound := histogram.Buckets[i+1]
+			if math.IsInf(bound, 1) {
+				bound = histogram.Buckets[i]
+			}
+			c <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, bound, strconv.FormatFloat(quantile, 'g', -1, 64))
+			break
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"math"
+	"reflect"
+	"runtime/metrics"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// quantiles returns the values collected by collectQuantiles by quantile label
+func quantiles(t *testing.T, histogram *metrics.Float64Histogram) map[string]float64 {
+	t.Helper()
+	desc := prometheus.NewDesc("test_quantiles", "", []string{"quantile"}, nil)
+	c := make(chan prometheus.Metric, 10)
+	collectQuantiles(c, desc, histogram)
+	close(c)
+	values := make(map[string]float64)
+	for metric := range c {
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		values[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+	}
+	return values
+}
+
+func TestCollectQuantiles(t *testing.T) {
+	histogram := &metrics.Float64Histogram{
+		Counts:  []uint64{2, 1, 1},
+		Buckets: []float64{0, 1, 2, math.Inf(1)},
+	}
+	// the quantiles of the +Inf bucket take its lower bound
+	want := map[string]float64{"0.5": 1, "0.9": 2, "0.99": 2, "1": 2}
+	if got := quantiles(t, histogram); !reflect.DeepEqual(got, want) {
+		t.Errorf("quantiles = %v, want %v", got, want)
+	}
+}
+
+func TestCollectQuantilesEmpty(t *testing.T) {
+	empty := &metrics.Float64Histogram{Counts: []uint64{0}, Buckets: []float64{0, 1}}
+	for name, histogram := range map[string]*metrics.Float64Histogram{"empty": empty, "unsupported": nil} {
+		if got := quantiles(t, histogram); len(got) > 0 {
+			t.Errorf("%s: quantiles = %v, want none", name, got)
+		}
+	}
+}
+
+func TestRuntimeMetricsCgroup(t *testing.T) {
+	cgroupFixture(t, map[string]string{
+		"cgroup.controllers": "cpu memory",
+		"cpu.max":            "200000 100000",
+		"memory.max":         "max",
+		"cpu.stat":           "nr_periods 10\nnr_throttled 4\nthrottled_usec 2500000",
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewRuntimeMetrics(nil))
+
+	for name, want := range map[string]int{
+		"runtime_gomaxprocs":                 1,
+		"cgroup_cpu_quota_cores":             1,
+		"cgroup_memory_limit_bytes":          0,
+		"cgroup_cpu_throttled_seconds_total": 1,
+	} {
+		if got, err := testutil.GatherAndCount(registry, name); err != nil || got != want {
+			t.Errorf("%s: %d series, %v, want %d", name, got, err, want)
+		}
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == "cgroup_cpu_quota_cores" {
+			if got := family.GetMetric()[0].GetGauge().GetValue(); got != 2 {
+				t.Errorf("cgroup_cpu_quota_cores = %v, want 2", got)
+			}
+		}
+	}
+}
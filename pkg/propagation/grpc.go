@@ -0,0 +1,96 @@
+package propagation
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func NewUnaryServerInterceptor(keys []string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		return handler(extractIncoming(ctx, keys), req)
+	}
+}
+
+func NewStreamServerInterceptor(keys []string) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: extractIncoming(ss.Context(), keys)})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// extractIncoming stores the allowed keys of the incoming metadata in ctx
+func extractIncoming(ctx context.Context, keys []string) context.Context {
+	requestMetadata, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	values := map[string]string{}
+	for _, key := range keys {
+		if value := requestMetadata.Get(key); len(value) > 0 {
+			values[key] = value[0]
+		}
+	}
+	return ContextWithValues(ctx, values)
+}
+
+func NewUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		callOpts ...grpc.CallOption,
+	) error {
+		return invoker(injectOutgoing(ctx), method, req, reply, cc, callOpts...)
+	}
+}
+
+func NewStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		callOpts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		return streamer(injectOutgoing(ctx), desc, cc, method, callOpts...)
+	}
+}
+
+// injectOutgoing adds the forwarded values to the outgoing metadata, values set explicitly by the caller are kept
+func injectOutgoing(ctx context.Context) context.Context {
+	values := FromContext(ctx)
+	if len(values) == 0 {
+		return ctx
+	}
+	outgoing, _ := metadata.FromOutgoingContext(ctx)
+	var pairs []string
+	for key, value := range values {
+		if len(outgoing.Get(key)) == 0 {
+			pairs = append(pairs, key, value)
+		}
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
@@ -0,0 +1,79 @@
+package propagation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var testKeys = []string{"request-id", "user-id"}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"request-id", "r1", "User-Id", "u1", "authorization", "secret"))
+	var values map[string]string
+	_, err := NewUnaryServerInterceptor(testKeys)(ctx, nil, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			values = FromContext(ctx)
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"request-id": "r1", "user-id": "u1"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("forwarded %v, want %v", values, want)
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("request-id", "r1", "locale", "vi"))
+	err := NewStreamServerInterceptor(testKeys)(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			if values := FromContext(stream.Context()); !reflect.DeepEqual(values, map[string]string{"request-id": "r1"}) {
+				t.Errorf("forwarded %v", values)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	ctx := ContextWithValues(context.Background(), map[string]string{"request-id": "r1", "user-id": "u1"})
+	// set explicitly by the caller
+	ctx = metadata.AppendToOutgoingContext(ctx, "user-id", "u2")
+	err := NewUnaryClientInterceptor()(ctx, "/pkg.Service/Get", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			outgoing, _ := metadata.FromOutgoingContext(ctx)
+			if got := outgoing.Get("request-id"); !reflect.DeepEqual(got, []string{"r1"}) {
+				t.Errorf("request-id = %v", got)
+			}
+			if got := outgoing.Get("user-id"); !reflect.DeepEqual(got, []string{"u2"}) {
+				t.Errorf("user-id = %v, the value of the caller should be kept", got)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInjectOutgoingWithoutValues(t *testing.T) {
+	ctx := context.Background()
+	if got := injectOutgoing(ctx); got != ctx {
+		t.Error("the context should be unchanged")
+	}
+}
@@ -0,0 +1,44 @@
+package propagation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// InjectKafkaHeaders sets the forwarded values in the headers of msg, replacing the headers with the same key
+func InjectKafkaHeaders(ctx context.Context, msg *sarama.ProducerMessage) {
+	values := FromContext(ctx)
+	if len(values) == 0 {
+		return
+	}
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+len(values))
+	for _, header := range msg.Headers {
+		if _, forwarded := values[strings.ToLower(string(header.Key))]; !forwarded {
+			headers = append(headers, header)
+		}
+	}
+	for key, value := range values {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
+	msg.Headers = headers
+}
+
+// ExtractKafkaHeaders stores the allowed keys of the headers of msg in ctx
+func ExtractKafkaHeaders(ctx context.Context, msg *sarama.ConsumerMessage, keys []string) context.Context {
+	values := map[string]string{}
+	for _, header := range msg.Headers {
+		if header == nil {
+			continue
+		}
+		key := strings.ToLower(string(header.Key))
+		for _, allowed := range keys {
+			if key == allowed {
+				values[key] = string(header.Value)
+				break
+			}
+		}
+	}
+	return ContextWithValues(ctx, values)
+}
@@ -0,0 +1,45 @@
+package propagation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestKafkaHeadersRoundTrip(t *testing.T) {
+	ctx := ContextWithValues(context.Background(), map[string]string{"request-id": "r1", "user-id": "u1"})
+	msg := &sarama.ProducerMessage{Headers: []sarama.RecordHeader{
+		{Key: []byte("Request-Id"), Value: []byte("stale")},
+		{Key: []byte("content-type"), Value: []byte("json")},
+	}}
+	InjectKafkaHeaders(ctx, msg)
+	// a retried message is injected again
+	InjectKafkaHeaders(ctx, msg)
+
+	headers := map[string][]string{}
+	consumed := &sarama.ConsumerMessage{}
+	for _, header := range msg.Headers {
+		header := header
+		headers[string(header.Key)] = append(headers[string(header.Key)], string(header.Value))
+		consumed.Headers = append(consumed.Headers, &header)
+	}
+	want := map[string][]string{"request-id": {"r1"}, "user-id": {"u1"}, "content-type": {"json"}}
+	if !reflect.DeepEqual(headers, want) {
+		t.Errorf("headers = %v, want %v", headers, want)
+	}
+
+	extracted := FromContext(ExtractKafkaHeaders(context.Background(), consumed, []string{"request-id"}))
+	if !reflect.DeepEqual(extracted, map[string]string{"request-id": "r1"}) {
+		t.Errorf("extracted %v", extracted)
+	}
+}
+
+func TestInjectKafkaHeadersWithoutValues(t *testing.T) {
+	msg := &sarama.ProducerMessage{Headers: []sarama.RecordHeader{{Key: []byte("request-id"), Value: []byte("r1")}}}
+	InjectKafkaHeaders(context.Background(), msg)
+	if len(msg.Headers) != 1 || string(msg.Headers[0].Value) != "r1" {
+		t.Errorf("headers = %v", msg.Headers)
+	}
+}
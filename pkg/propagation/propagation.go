@@ -0,0 +1,51 @@
+package propagation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultKeys are forwarded when propagation.metadata-keys is not set
+var DefaultKeys = []string{"request-id", "user-id", "locale", "baggage"}
+
+type propagatedKey struct{}
+
+// Keys returns the allowlist of metadata keys forwarded from inbound to outbound calls
+func Keys() []string {
+	if !viper.IsSet("propagation.metadata-keys") {
+		return DefaultKeys
+	}
+	keys := viper.GetStringSlice("propagation.metadata-keys")
+	for i, key := range keys {
+		keys[i] = strings.ToLower(key)
+	}
+	return keys
+}
+
+// ContextWithValues stores the values to forward in ctx, merged with the values already stored
+func ContextWithValues(ctx context.Context, values map[string]string) context.Context {
+	if len(values) == 0 {
+		return ctx
+	}
+	merged := make(map[string]string, len(values))
+	for key, value := range FromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[strings.ToLower(key)] = value
+	}
+	return context.WithValue(ctx, propagatedKey{}, merged)
+}
+
+// FromContext returns the values to forward, it must not be modified
+func FromContext(ctx context.Context) map[string]string {
+	values, _ := ctx.Value(propagatedKey{}).(map[string]string)
+	return values
+}
+
+// Get returns a forwarded value
+func Get(ctx context.Context, key string) string {
+	return FromContext(ctx)[strings.ToLower(key)]
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/nmtri1912/go-common/pkg/monitor"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -12,9 +14,9 @@ type (
 	Hook struct {
 		options *Options
 
-		commandCounter      *prometheus.CounterVec
-		commandErrorCounter *prometheus.CounterVec
-		commandDuration     *prometheus.HistogramVec
+		commandCounter      *metrics.LimitedVec[prometheus.Counter]
+		commandErrorCounter *metrics.LimitedVec[prometheus.Counter]
+		commandDuration     *metrics.LimitedVec[prometheus.Observer]
 	}
 
 	startKey struct{}
@@ -24,27 +26,31 @@ var (
 	labelNames = []string{"command"}
 )
 
-// NewHook creates a new go-redis hook instance and registers Prometheus collectors.
+// NewHook creates a new go-redis hook instance and registers Prometheus collectors in the registerer
+// of WithRegisterer, prometheus.DefaultRegisterer by default.
 func NewHook(opts ...Option) *Hook {
 	options := DefaultOptions()
 	options.Merge(opts...)
 
-	commandCounter := register(prometheus.NewCounterVec(prometheus.CounterOpts{
+	// commands sent with Do are free-form
+	commandCounter := metrics.RegisterLimited[prometheus.Counter](options.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "redis_command_total",
 		Help: "Total redis command",
-	}, labelNames)).(*prometheus.CounterVec)
+	}, labelNames), monitor.NewLabelLimiter("redis_command_total", labelNames))
 
-	commandErrorCounter := register(prometheus.NewCounterVec(prometheus.CounterOpts{
+	commandErrorCounter := metrics.RegisterLimited[prometheus.Counter](options.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "redis_command_error_total",
 		Help: "Total error redis command",
-	}, labelNames)).(*prometheus.CounterVec)
+	}, labelNames), monitor.NewLabelLimiter("redis_command_error_total", labelNames))
 
-	commandDuration := register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	durationOpts := prometheus.HistogramOpts{
 		Namespace: options.Namespace,
 		Name:      "redis_command_duration",
 		Help:      "Redis command latencies in seconds",
-		Buckets:   options.DurationBuckets,
-	}, labelNames)).(*prometheus.HistogramVec)
+	}
+	monitor.HistogramSettings{Buckets: options.DurationBuckets, Native: options.NativeHistogram}.Apply(&durationOpts)
+	commandDuration := metrics.RegisterLimited[prometheus.Observer](options.Registerer, prometheus.NewHistogramVec(durationOpts, labelNames),
+		monitor.NewLabelLimiter("redis_command_duration", labelNames))
 
 	return &Hook{
 		options:             options,
@@ -85,19 +91,6 @@ func (hook *Hook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder)
 	return nil
 }
 
-func register(collector prometheus.Collector) prometheus.Collector {
-	err := prometheus.DefaultRegisterer.Register(collector)
-	if err == nil {
-		return collector
-	}
-
-	if arErr, ok := err.(prometheus.AlreadyRegisteredError); ok {
-		return arErr.ExistingCollector
-	}
-
-	panic(err)
-}
-
 func isActualErr(err error) bool {
 	return err != nil && err != redis.Nil
 }
@@ -1,22 +1,31 @@
 package redisprom
 
-import "github.com/nmtri1912/go-common/pkg/monitor"
+import (
+	"github.com/nmtri1912/go-common/pkg/monitor"
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 type (
 	// Options represents options to customize the exported metrics.
 	Options struct {
 		Namespace       string
 		DurationBuckets []float64
+		// NativeHistogram enables the native histogram of command durations
+		NativeHistogram *monitor.NativeHistogramOpts
+		Registerer      prometheus.Registerer
 	}
 
 	Option func(*Options)
 )
 
-// DefaultOptions returns the default options.
+// DefaultOptions returns the default options, the duration histogram is configured by monitor.histograms.redis.
 func DefaultOptions() *Options {
+	durationSettings := monitor.LoadHistogramSettings("redis", monitor.GetHistorgramBuckets())
 	return &Options{
 		Namespace:       "",
-		DurationBuckets: monitor.GetHistorgramBuckets(),
+		DurationBuckets: durationSettings.Buckets,
+		NativeHistogram: durationSettings.Native,
+		Registerer:      prometheus.DefaultRegisterer,
 	}
 }
 
@@ -39,3 +48,17 @@ func WithDurationBuckets(buckets []float64) Option {
 		options.DurationBuckets = buckets
 	}
 }
+
+// WithNativeHistogram enables the native histogram of single commands durations.
+func WithNativeHistogram(native *monitor.NativeHistogramOpts) Option {
+	return func(options *Options) {
+		options.NativeHistogram = native
+	}
+}
+
+// WithRegisterer sets the registerer of the collectors.
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(options *Options) {
+		options.Registerer = registerer
+	}
+}
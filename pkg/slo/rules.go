@@ -0,0 +1,137 @@
+package slo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// burnRateAlert fires when both windows consume more than budget of the error budget of the period,
+// from the multiwindow, multi-burn-rate alerts of the Google SRE workbook
+type burnRateAlert struct {
+	severity    string
+	budget      float64
+	longWindow  time.Duration
+	shortWindow time.Duration
+}
+
+var burnRateAlerts = []burnRateAlert{
+	{severity: "page", budget: 0.02, longWindow: time.Hour, shortWindow: 5 * time.Minute},
+	{severity: "page", budget: 0.05, longWindow: 6 * time.Hour, shortWindow: 30 * time.Minute},
+	{severity: "ticket", budget: 0.10, longWindow: 3 * 24 * time.Hour, shortWindow: 6 * time.Hour},
+}
+
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// GenerateRules returns the Prometheus recording and alerting rules of the objectives, reading
+// invocations_seconds and invocations_errors_total of application, all applications if empty.
+// The recorded series and the alerts are labelled by application
+func GenerateRules(config Config, application string) ([]byte, error) {
+	if err := config.validateThresholds(); err != nil {
+		return nil, err
+	}
+	windows := make(map[time.Duration]bool)
+	for _, alert := range burnRateAlerts {
+		windows[alert.longWindow] = true
+		windows[alert.shortWindow] = true
+	}
+	sortedWindows := make([]time.Duration, 0, len(windows))
+	for window := range windows {
+		sortedWindows = append(sortedWindows, window)
+	}
+	sort.Slice(sortedWindows, func(i, j int) bool { return sortedWindows[i] < sortedWindows[j] })
+
+	var file ruleFile
+	for i := range config.Objectives {
+		objective := &config.Objectives[i]
+		group := ruleGroup{Name: "slo-" + objective.Name}
+		for _, indicator := range objective.indicators() {
+			labels := map[string]string{"slo": objective.Name, "sli": indicator.sli}
+			for _, window := range sortedWindows {
+				group.Rules = append(group.Rules, rule{
+					Record: recordName(window),
+					Expr:   errorRatioExpr(config, indicator, application, FormatWindow(window)),
+					Labels: labels,
+				})
+			}
+			for _, alert := range burnRateAlerts {
+				factor := alert.budget * float64(config.Period) / float64(alert.longWindow)
+				threshold := strconv.FormatFloat(round(factor*(1-indicator.target)), 'g', -1, 64)
+				selector := fmt.Sprintf(`{slo="%s",sli="%s"}`, objective.Name, indicator.sli)
+				group.Rules = append(group.Rules, rule{
+					Alert: "SLOErrorBudgetBurn",
+					Expr: fmt.Sprintf("%s%s > %s\nand\n%s%s > %s",
+						recordName(alert.longWindow), selector, threshold,
+						recordName(alert.shortWindow), selector, threshold),
+					Labels: map[string]string{"slo": objective.Name, "sli": indicator.sli, "severity": alert.severity},
+					Annotations: map[string]string{
+						"summary": fmt.Sprintf("%s %s SLO burns %s of its %s error budget in %s",
+							objective.Name, indicator.sli, strconv.FormatFloat(alert.budget*100, 'g', -1, 64)+"%",
+							FormatWindow(config.Period), FormatWindow(alert.longWindow)),
+					},
+				})
+			}
+		}
+		file.Groups = append(file.Groups, group)
+	}
+	return yaml.Marshal(file)
+}
+
+func recordName(window time.Duration) string {
+	return "slo:sli_error:ratio_rate" + FormatWindow(window)
+}
+
+func errorRatioExpr(config Config, indicator indicator, application, window string) string {
+	selector := matchers(indicator.objective, application)
+	count := fmt.Sprintf("sum by (application) (rate(invocations_seconds_count{%s}[%s]))", selector, window)
+	if indicator.sli == SLILatency {
+		// validated by validateThresholds, the bound is formatted like the exposition formats
+		bound, _ := config.bound(indicator.threshold)
+		le := fmt.Sprintf(`le="%s"`, strconv.FormatFloat(bound, 'g', -1, 64))
+		if bound == math.Trunc(bound) {
+			// integer bounds are formatted 1 or 1.0 depending on the exposition format
+			le = fmt.Sprintf(`le=~"%s(\\.0)?"`, strconv.FormatFloat(bound, 'f', -1, 64))
+		}
+		if len(selector) > 0 {
+			le = selector + "," + le
+		}
+		return fmt.Sprintf("1 - (\n  sum by (application) (rate(invocations_seconds_bucket{%s}[%s]))\n/\n  %s\n)", le, window, count)
+	}
+	// 0 * count keeps the applications without error
+	return fmt.Sprintf("(\n  sum by (application) (rate(invocations_errors_total{%s}[%s]))\nor\n  0 * %s\n)\n/\n%s", selector, window, count, count)
+}
+
+func matchers(objective *Objective, application string) string {
+	var matchers []string
+	for _, label := range [][2]string{{"application", application}, {"name", objective.Service}, {"type", objective.Type}, {"method", objective.Method}} {
+		if len(label[1]) > 0 {
+			matchers = append(matchers, fmt.Sprintf(`%s="%s"`, label[0], label[1]))
+		}
+	}
+	return strings.Join(matchers, ",")
+}
+
+// round removes the floating point noise of the thresholds, e.g. 0.014400000000000001
+func round(value float64) float64 {
+	return math.Round(value*1e9) / 1e9
+}
@@ -0,0 +1,95 @@
+package slo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmtri1912/go-common/pkg/monitor"
+	"gopkg.in/yaml.v3"
+)
+
+func testConfig(thresholdMs int) Config {
+	return Config{
+		Objectives: []Objective{{
+			Name:         "create-order",
+			Service:      "order",
+			Method:       "Create",
+			Availability: 0.999,
+			Latency:      &LatencyObjective{ThresholdMs: thresholdMs, Target: 0.99},
+		}},
+		Windows: DefaultWindows,
+		Period:  30 * 24 * time.Hour,
+		Buckets: monitor.FastRPCBuckets,
+	}
+}
+
+func generate(t *testing.T, config Config) []rule {
+	t.Helper()
+	output, err := GenerateRules(config, "order-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file ruleFile
+	if err := yaml.Unmarshal(output, &file); err != nil {
+		t.Fatal(err)
+	}
+	return file.Groups[0].Rules
+}
+
+func TestGenerateRulesThresholds(t *testing.T) {
+	var alerts []rule
+	for _, r := range generate(t, testConfig(250)) {
+		if len(r.Alert) > 0 && r.Labels["sli"] == SLIAvailability {
+			alerts = append(alerts, r)
+		}
+	}
+	// budget * period / long window * (1 - target): 0.02 * 720, 0.05 * 120, 0.1 * 10
+	want := []struct {
+		severity, expr string
+	}{
+		{"page", "slo:sli_error:ratio_rate1h{slo=\"create-order\",sli=\"availability\"} > 0.0144\nand\nslo:sli_error:ratio_rate5m{slo=\"create-order\",sli=\"availability\"} > 0.0144"},
+		{"page", "slo:sli_error:ratio_rate6h{slo=\"create-order\",sli=\"availability\"} > 0.006\nand\nslo:sli_error:ratio_rate30m{slo=\"create-order\",sli=\"availability\"} > 0.006"},
+		{"ticket", "slo:sli_error:ratio_rate3d{slo=\"create-order\",sli=\"availability\"} > 0.001\nand\nslo:sli_error:ratio_rate6h{slo=\"create-order\",sli=\"availability\"} > 0.001"},
+	}
+	if len(alerts) != len(want) {
+		t.Fatalf("%d availability alerts, want %d", len(alerts), len(want))
+	}
+	for i, alert := range alerts {
+		if alert.Labels["severity"] != want[i].severity || alert.Expr != want[i].expr {
+			t.Errorf("alert %d = %s %q, want %s %q", i, alert.Labels["severity"], alert.Expr, want[i].severity, want[i].expr)
+		}
+	}
+}
+
+func TestGenerateRulesRecords(t *testing.T) {
+	records := map[string]string{}
+	for _, r := range generate(t, testConfig(250)) {
+		if r.Record == "slo:sli_error:ratio_rate5m" {
+			records[r.Labels["sli"]] = r.Expr
+		}
+	}
+	selector := `application="order-service",name="order",method="Create"`
+	if expr := records[SLILatency]; !strings.Contains(expr, `invocations_seconds_bucket{`+selector+`,le="0.25"}[5m]`) {
+		t.Errorf("latency record = %s", expr)
+	}
+	for sli, expr := range records {
+		if strings.Count(expr, "sum by (application)") != strings.Count(expr, "sum") {
+			t.Errorf("%s record should keep the application label: %s", sli, expr)
+		}
+	}
+
+	// integer bounds are matched in both exposition formats
+	for _, r := range generate(t, testConfig(1000)) {
+		if r.Record == "slo:sli_error:ratio_rate5m" && r.Labels["sli"] == SLILatency && !strings.Contains(r.Expr, `le=~"1(\\.0)?"`) {
+			t.Errorf("latency record = %s", r.Expr)
+		}
+	}
+}
+
+func TestGenerateRulesInvalidThreshold(t *testing.T) {
+	_, err := GenerateRules(testConfig(300), "")
+	if err == nil || !strings.Contains(err.Error(), "0.25s and 0.5s") {
+		t.Errorf("GenerateRules() = %v, want the nearest buckets", err)
+	}
+}
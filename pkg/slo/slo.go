@@ -0,0 +1,232 @@
+package slo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nmtri1912/go-common/pkg/monitor"
+	"github.com/spf13/viper"
+)
+
+// Indicators of an objective
+const (
+	SLIAvailability = "availability"
+	SLILatency      = "latency"
+)
+
+const defaultPeriod = 30 * 24 * time.Hour
+
+// DefaultWindows are the burn rate windows of the multiwindow, multi-burn-rate alerts
+var DefaultWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour, 3 * 24 * time.Hour}
+
+// Objective targets the invocations recorded by monitor.Timer with the given labels,
+// an empty label matches any value
+type Objective struct {
+	Name    string `mapstructure:"name"`
+	Service string `mapstructure:"service"`
+	Type    string `mapstructure:"type"`
+	Method  string `mapstructure:"method"`
+	// Availability is the target ratio of invocations without error, 0 to skip
+	Availability float64           `mapstructure:"availability"`
+	Latency      *LatencyObjective `mapstructure:"latency"`
+}
+
+// LatencyObjective is the target ratio of invocations lasting at most ThresholdMs
+type LatencyObjective struct {
+	ThresholdMs int     `mapstructure:"threshold-ms"`
+	Target      float64 `mapstructure:"target"`
+}
+
+// Config is the slo.* config
+type Config struct {
+	Objectives []Objective
+	// Windows of the burn rates
+	Windows []time.Duration
+	// Period of the error budget
+	Period time.Duration
+	// Buckets of invocations_seconds, the latency thresholds must be one of them. Default is monitor.GetHistorgramBuckets
+	Buckets []float64
+}
+
+// indicator is an SLI of an objective
+type indicator struct {
+	objective *Objective
+	sli       string
+	target    float64
+	threshold time.Duration
+}
+
+func (o *Objective) indicators() []indicator {
+	var indicators []indicator
+	if o.Availability > 0 {
+		indicators = append(indicators, indicator{objective: o, sli: SLIAvailability, target: o.Availability})
+	}
+	if o.Latency != nil && o.Latency.Target > 0 {
+		indicators = append(indicators, indicator{
+			objective: o,
+			sli:       SLILatency,
+			target:    o.Latency.Target,
+			threshold: time.Duration(o.Latency.ThresholdMs) * time.Millisecond,
+		})
+	}
+	return indicators
+}
+
+func (o *Objective) matches(name, metricType, method string) bool {
+	return (len(o.Service) == 0 || o.Service == name) &&
+		(len(o.Type) == 0 || o.Type == metricType) &&
+		(len(o.Method) == 0 || o.Method == method)
+}
+
+// bad tells whether an invocation consumes the error budget of the indicator
+func (i indicator) bad(duration time.Duration, err error) bool {
+	if i.sli == SLILatency {
+		return duration > i.threshold
+	}
+	return err != nil
+}
+
+func (o *Objective) validate() error {
+	if len(o.Name) == 0 {
+		return fmt.Errorf("slo name is required")
+	}
+	if o.Availability < 0 || o.Availability >= 1 {
+		return fmt.Errorf("slo %s: availability must be in [0, 1)", o.Name)
+	}
+	if o.Latency != nil {
+		if o.Latency.Target <= 0 || o.Latency.Target >= 1 {
+			return fmt.Errorf("slo %s: latency target must be in (0, 1)", o.Name)
+		}
+		if o.Latency.ThresholdMs <= 0 {
+			return fmt.Errorf("slo %s: latency threshold-ms is required", o.Name)
+		}
+	}
+	if len(o.indicators()) == 0 {
+		return fmt.Errorf("slo %s has no availability nor latency objective", o.Name)
+	}
+	return nil
+}
+
+// LoadConfig reads slo.objectives, slo.windows and slo.period-days, and the buckets of invocations_seconds
+// from monitor.histograms.invocations
+func LoadConfig() (Config, error) {
+	config := Config{
+		Windows: DefaultWindows,
+		Period:  defaultPeriod,
+		Buckets: monitor.LoadHistogramSettings("invocations", monitor.GetHistorgramBuckets()).Buckets,
+	}
+	if err := viper.UnmarshalKey("slo.objectives", &config.Objectives); err != nil {
+		return config, err
+	}
+	names := make(map[string]bool)
+	for i := range config.Objectives {
+		if err := config.Objectives[i].validate(); err != nil {
+			return config, err
+		}
+		if names[config.Objectives[i].Name] {
+			return config, fmt.Errorf("duplicate slo %s", config.Objectives[i].Name)
+		}
+		names[config.Objectives[i].Name] = true
+	}
+	if err := config.validateThresholds(); err != nil {
+		return config, err
+	}
+	if windows := viper.GetStringSlice("slo.windows"); len(windows) > 0 {
+		config.Windows = nil
+		for _, window := range windows {
+			duration, err := ParseWindow(window)
+			if err != nil {
+				return config, err
+			}
+			config.Windows = append(config.Windows, duration)
+		}
+	}
+	if days := viper.GetInt("slo.period-days"); days > 0 {
+		config.Period = time.Duration(days) * 24 * time.Hour
+	}
+	return config, nil
+}
+
+// validateThresholds checks that the latency thresholds are bounds of invocations_seconds, the buckets read by the rules
+func (c Config) validateThresholds() error {
+	for i := range c.Objectives {
+		objective := &c.Objectives[i]
+		if objective.Latency == nil {
+			continue
+		}
+		threshold := time.Duration(objective.Latency.ThresholdMs) * time.Millisecond
+		if _, exist := c.bound(threshold); !exist {
+			below, above := c.nearestBounds(threshold.Seconds())
+			return fmt.Errorf("slo %s: latency threshold-ms %d is not a bucket of invocations_seconds "+
+				"(monitor.histograms.invocations, e.g. preset fast-rpc), the nearest buckets are %gs and %gs",
+				objective.Name, objective.Latency.ThresholdMs, below, above)
+		}
+	}
+	return nil
+}
+
+func (c Config) buckets() []float64 {
+	if len(c.Buckets) == 0 {
+		return monitor.GetHistorgramBuckets()
+	}
+	return c.Buckets
+}
+
+// bound returns the bucket of invocations_seconds equal to threshold
+func (c Config) bound(threshold time.Duration) (float64, bool) {
+	seconds := threshold.Seconds()
+	for _, bucket := range c.buckets() {
+		if math.Abs(bucket-seconds) <= 1e-9*math.Max(1, seconds) {
+			return bucket, true
+		}
+	}
+	return 0, false
+}
+
+func (c Config) nearestBounds(seconds float64) (below, above float64) {
+	buckets := append([]float64(nil), c.buckets()...)
+	sort.Float64s(buckets)
+	i := sort.SearchFloat64s(buckets, seconds)
+	below, above = 0, math.Inf(1)
+	if i > 0 {
+		below = buckets[i-1]
+	}
+	if i < len(buckets) {
+		above = buckets[i]
+	}
+	return below, above
+}
+
+// ParseWindow parses a Prometheus duration like 5m, 6h or 3d
+func ParseWindow(window string) (time.Duration, error) {
+	if strings.HasSuffix(window, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(window, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", window)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q", window)
+	}
+	return duration, nil
+}
+
+// FormatWindow formats a window as a Prometheus duration, e.g. 5m, 6h or 3d
+func FormatWindow(window time.Duration) string {
+	switch {
+	case window%(24*time.Hour) == 0:
+		return strconv.Itoa(int(window/(24*time.Hour))) + "d"
+	case window%time.Hour == 0:
+		return strconv.Itoa(int(window/time.Hour)) + "h"
+	case window%time.Minute == 0:
+		return strconv.Itoa(int(window/time.Minute)) + "m"
+	default:
+		return strconv.Itoa(int(window/time.Second)) + "s"
+	}
+}
@@ -0,0 +1,57 @@
+package slo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("slo.objectives", []map[string]interface{}{{
+		"name":    "create-order",
+		"service": "order",
+		"latency": map[string]interface{}{"threshold-ms": 250, "target": 0.99},
+	}})
+	viper.Set("slo.windows", []string{"1h", "3d"})
+	viper.Set("slo.period-days", 28)
+
+	// 250ms is not a bucket of the default buckets
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "threshold-ms 250") {
+		t.Errorf("LoadConfig() = %v, want a threshold error", err)
+	}
+
+	viper.Set("monitor.histograms.invocations.preset", "fast-rpc")
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Objectives) != 1 || config.Objectives[0].Latency.ThresholdMs != 250 {
+		t.Errorf("objectives = %+v", config.Objectives)
+	}
+	if len(config.Windows) != 2 || config.Windows[1] != 72*time.Hour || config.Period != 28*24*time.Hour {
+		t.Errorf("windows = %v, period = %v", config.Windows, config.Period)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	for window, duration := range map[string]time.Duration{
+		"5m": 5 * time.Minute,
+		"6h": 6 * time.Hour,
+		"3d": 72 * time.Hour,
+		"1d": 24 * time.Hour,
+	} {
+		parsed, err := ParseWindow(window)
+		if err != nil || parsed != duration {
+			t.Errorf("ParseWindow(%q) = %v, %v", window, parsed, err)
+		}
+		if formatted := FormatWindow(duration); formatted != window {
+			t.Errorf("FormatWindow(%v) = %q, want %q", duration, formatted, window)
+		}
+	}
+	if _, err := ParseWindow("xd"); err == nil {
+		t.Error("ParseWindow(xd) should fail")
+	}
+}
@@ -0,0 +1,164 @@
+package slo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	windowSlot = time.Minute
+	periodSlot = time.Hour
+)
+
+// Tracker computes the burn rates and error budgets of the objectives from the invocations it observes.
+// They are computed in process, since the start of the process
+type Tracker struct {
+	config Config
+	now    func() time.Time
+	series []*series
+
+	targetDesc   *prometheus.Desc
+	burnRateDesc *prometheus.Desc
+	budgetDesc   *prometheus.Desc
+}
+
+type series struct {
+	indicator
+	mutex   sync.Mutex
+	windows *ring
+	period  *ring
+}
+
+// NewTracker tracks the objectives of config, its metrics have constLabels, e.g. the ones of the monitor recorder
+func NewTracker(config Config, constLabels prometheus.Labels) *Tracker {
+	longest := time.Duration(0)
+	for _, window := range config.Windows {
+		if window > longest {
+			longest = window
+		}
+	}
+	tracker := &Tracker{
+		config:       config,
+		now:          time.Now,
+		targetDesc:   prometheus.NewDesc("slo_objective_target", "Target ratio of good invocations", []string{"slo", "sli"}, constLabels),
+		burnRateDesc: prometheus.NewDesc("slo_burn_rate", "Error budget consumption rate over the window, 1 consumes the budget in exactly the period", []string{"slo", "sli", "window"}, constLabels),
+		budgetDesc:   prometheus.NewDesc("slo_error_budget_remaining", "Ratio of the error budget left over the period, negative when exhausted", []string{"slo", "sli"}, constLabels),
+	}
+	for i := range config.Objectives {
+		for _, indicator := range config.Objectives[i].indicators() {
+			tracker.series = append(tracker.series, &series{
+				indicator: indicator,
+				windows:   newRing(windowSlot, longest),
+				period:    newRing(periodSlot, config.Period),
+			})
+		}
+	}
+	return tracker
+}
+
+// Observe is a monitor.InvocationObserver, register it with MonitorRecorder.AddObserver
+func (t *Tracker) Observe(name, metricType, method string, duration time.Duration, err error) {
+	now := t.now()
+	for _, s := range t.series {
+		if !s.objective.matches(name, metricType, method) {
+			continue
+		}
+		bad := s.bad(duration, err)
+		s.mutex.Lock()
+		s.windows.add(now, bad)
+		s.period.add(now, bad)
+		s.mutex.Unlock()
+	}
+}
+
+// BurnRate returns the rate of consumption of the error budget of an indicator over window
+func (t *Tracker) BurnRate(slo, sli string, window time.Duration) float64 {
+	for _, s := range t.series {
+		if s.objective.Name == slo && s.sli == sli {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			return s.burnRate(s.windows.sum(t.now(), window))
+		}
+	}
+	return 0
+}
+
+// BudgetRemaining returns the ratio of the error budget of an indicator left over the period
+func (t *Tracker) BudgetRemaining(slo, sli string) float64 {
+	for _, s := range t.series {
+		if s.objective.Name == slo && s.sli == sli {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			return 1 - s.burnRate(s.period.sum(t.now(), t.config.Period))
+		}
+	}
+	return 1
+}
+
+func (s *series) burnRate(total, bad uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(bad) / float64(total) / (1 - s.target)
+}
+
+func (t *Tracker) Describe(c chan<- *prometheus.Desc) {
+	c <- t.targetDesc
+	c <- t.burnRateDesc
+	c <- t.budgetDesc
+}
+
+func (t *Tracker) Collect(c chan<- prometheus.Metric) {
+	now := t.now()
+	for _, s := range t.series {
+		s.mutex.Lock()
+		c <- prometheus.MustNewConstMetric(t.targetDesc, prometheus.GaugeValue, s.target, s.objective.Name, s.sli)
+		for _, window := range t.config.Windows {
+			c <- prometheus.MustNewConstMetric(t.burnRateDesc, prometheus.GaugeValue,
+				s.burnRate(s.windows.sum(now, window)), s.objective.Name, s.sli, FormatWindow(window))
+		}
+		c <- prometheus.MustNewConstMetric(t.budgetDesc, prometheus.GaugeValue,
+			1-s.burnRate(s.period.sum(now, t.config.Period)), s.objective.Name, s.sli)
+		s.mutex.Unlock()
+	}
+}
+
+// ring counts the events by time slot over a duration
+type ring struct {
+	slot  time.Duration
+	ids   []int64
+	total []uint64
+	bad   []uint64
+}
+
+func newRing(slot, duration time.Duration) *ring {
+	size := int(duration/slot) + 1
+	return &ring{slot: slot, ids: make([]int64, size), total: make([]uint64, size), bad: make([]uint64, size)}
+}
+
+func (r *ring) add(now time.Time, bad bool) {
+	id := now.UnixNano() / int64(r.slot)
+	i := int(id % int64(len(r.ids)))
+	if r.ids[i] != id {
+		r.ids[i], r.total[i], r.bad[i] = id, 0, 0
+	}
+	r.total[i]++
+	if bad {
+		r.bad[i]++
+	}
+}
+
+// sum counts the events of the slots overlapping the window ending at now
+func (r *ring) sum(now time.Time, window time.Duration) (total, bad uint64) {
+	last := now.UnixNano() / int64(r.slot)
+	first := last - int64((window+r.slot-1)/r.slot) + 1
+	for i, id := range r.ids {
+		if id >= first && id <= last {
+			total += r.total[i]
+			bad += r.bad[i]
+		}
+	}
+	return total, bad
+}
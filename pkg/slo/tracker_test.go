@@ -0,0 +1,125 @@
+package slo
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRingWindows(t *testing.T) {
+	r := newRing(time.Minute, 10*time.Minute)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.add(start, false)
+	r.add(start.Add(30*time.Second), true)
+	r.add(start.Add(5*time.Minute), true)
+	r.add(start.Add(9*time.Minute), false)
+
+	tests := []struct {
+		now        time.Duration
+		window     time.Duration
+		total, bad uint64
+	}{
+		{now: 9 * time.Minute, window: time.Minute, total: 1, bad: 0},
+		{now: 9 * time.Minute, window: 5 * time.Minute, total: 2, bad: 1},
+		{now: 9 * time.Minute, window: 10 * time.Minute, total: 4, bad: 2},
+		// the slots of the first minute are out of the window
+		{now: 10 * time.Minute, window: 10 * time.Minute, total: 2, bad: 1},
+		{now: 30 * time.Minute, window: 10 * time.Minute, total: 0, bad: 0},
+	}
+	for _, test := range tests {
+		total, bad := r.sum(start.Add(test.now), test.window)
+		if total != test.total || bad != test.bad {
+			t.Errorf("sum(%v, %v) = %d, %d, want %d, %d", test.now, test.window, total, bad, test.total, test.bad)
+		}
+	}
+
+	// a slot is reused once the ring wrapped around
+	r.add(start.Add(11*time.Minute), true)
+	if total, bad := r.sum(start.Add(11*time.Minute), time.Minute); total != 1 || bad != 1 {
+		t.Errorf("reused slot = %d, %d, want 1, 1", total, bad)
+	}
+}
+
+func testTracker(now *time.Time) *Tracker {
+	tracker := NewTracker(Config{
+		Objectives: []Objective{{
+			Name:         "create-order",
+			Service:      "order",
+			Availability: 0.99,
+			Latency:      &LatencyObjective{ThresholdMs: 250, Target: 0.9},
+		}},
+		Windows: []time.Duration{5 * time.Minute, time.Hour},
+		Period:  24 * time.Hour,
+	}, prometheus.Labels{"application": "order"})
+	tracker.now = func() time.Time { return *now }
+	return tracker
+}
+
+func TestTrackerBurnRates(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := testTracker(&now)
+	// 100 invocations an hour ago, 10 of them failed and 20 slow
+	now = now.Add(-time.Hour + time.Minute)
+	for i := 0; i < 100; i++ {
+		var err error
+		if i < 10 {
+			err = errors.New("failed")
+		}
+		duration := 100 * time.Millisecond
+		if i >= 80 {
+			duration = 300 * time.Millisecond
+		}
+		tracker.Observe("order", "grpc", "Create", duration, err)
+	}
+	// other services are ignored
+	tracker.Observe("payment", "grpc", "Charge", time.Second, errors.New("failed"))
+	now = now.Add(time.Hour - time.Minute)
+
+	// 10% errors for a 1% budget
+	assertFloat(t, "availability 1h", tracker.BurnRate("create-order", SLIAvailability, time.Hour), 10)
+	assertFloat(t, "availability 5m", tracker.BurnRate("create-order", SLIAvailability, 5*time.Minute), 0)
+	// 20% slow for a 10% budget
+	assertFloat(t, "latency 1h", tracker.BurnRate("create-order", SLILatency, time.Hour), 2)
+	// the budget of the day: 1 - 10% / 1%
+	assertFloat(t, "availability budget", tracker.BudgetRemaining("create-order", SLIAvailability), -9)
+	assertFloat(t, "latency budget", tracker.BudgetRemaining("create-order", SLILatency), -1)
+	assertFloat(t, "unknown slo", tracker.BurnRate("unknown", SLILatency, time.Hour), 0)
+
+	// the invocations leave the period after a day
+	now = now.Add(24 * time.Hour)
+	assertFloat(t, "availability budget after the period", tracker.BudgetRemaining("create-order", SLIAvailability), 1)
+}
+
+func TestTrackerConstLabels(t *testing.T) {
+	now := time.Now()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(testTracker(&now))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(families) != 3 {
+		t.Fatalf("gathered %d families, want the 3 slo_* ones", len(families))
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["application"] != "order" {
+				t.Errorf("%s labels = %v, want the application", family.GetName(), labels)
+			}
+		}
+	}
+}
+
+func assertFloat(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
@@ -32,3 +32,33 @@ func ExtractReasonAndDomainFromError(err error, defaultDomain string) (codes.Cod
 	}
 	return status.Code(), errInfo.GetReason(), errInfo.GetDomain()
 }
+
+func NewGrpcBadRequestError(message, reason, domain string, violations []*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(codes.InvalidArgument, message)
+	nst, err := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: reason,
+			Domain: domain,
+		},
+		&errdetails.BadRequest{
+			FieldViolations: violations,
+		},
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return nst.Err()
+}
+
+func ExtractFieldViolationsFromError(err error) []*errdetails.BadRequest_FieldViolation {
+	status, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	for _, detail := range status.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			return badRequest.GetFieldViolations()
+		}
+	}
+	return nil
+}
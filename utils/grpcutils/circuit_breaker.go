@@ -0,0 +1,99 @@
+package grpcutils
+
+import (
+	"context"
+
+	"github.com/nmtri1912/go-common/pkg/circuitbreaker"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	circuitBreakerState = metrics.Shared(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_circuit_breaker_state",
+		Help: "State of gRPC client circuit breakers: 0 closed, 1 half-open, 2 open",
+	}, []string{"target"})).(*prometheus.GaugeVec)
+
+	circuitBreakerTransitions = metrics.Shared(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_circuit_breaker_transitions_total",
+		Help: "Number of state changes of gRPC client circuit breakers",
+	}, []string{"target", "state"})).(*prometheus.CounterVec)
+
+	circuitBreakerRejected = metrics.Shared(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_circuit_breaker_rejected_total",
+		Help: "Number of gRPC calls rejected by open circuit breakers",
+	}, []string{"target"})).(*prometheus.CounterVec)
+)
+
+func onCircuitBreakerStateChange(name string, from, to circuitbreaker.State) {
+	logger.L().Warn("Circuit breaker state changed",
+		zap.String("target", name),
+		zap.String("from", from.String()),
+		zap.String("to", to.String()),
+	)
+	circuitBreakerState.WithLabelValues(name).Set(float64(to))
+	circuitBreakerTransitions.WithLabelValues(name, to.String()).Inc()
+}
+
+// NewCircuitBreaker creates a breaker named after the target service, exporting its state as metrics
+func NewCircuitBreaker(service string, config circuitbreaker.Config) *circuitbreaker.Breaker {
+	circuitBreakerState.WithLabelValues(service).Set(float64(circuitbreaker.StateClosed))
+	return circuitbreaker.New(service, config, onCircuitBreakerStateChange)
+}
+
+// NewCircuitBreakerUnaryInterceptor rejects calls with codes.Unavailable while the breaker is open.
+// Only errors with one of failureCodes are counted as failures
+func NewCircuitBreakerUnaryInterceptor(breaker *circuitbreaker.Breaker, service string, failureCodes []codes.Code) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		callOpts ...grpc.CallOption,
+	) error {
+		done, err := breaker.Allow()
+		if err != nil {
+			circuitBreakerRejected.WithLabelValues(service).Inc()
+			return status.Errorf(codes.Unavailable, "%s: %v", service, err)
+		}
+		err = invoker(ctx, method, req, reply, cc, callOpts...)
+		done(!containsCode(failureCodes, status.Code(err)))
+		return err
+	}
+}
+
+func NewCircuitBreakerStreamInterceptor(breaker *circuitbreaker.Breaker, service string, failureCodes []codes.Code) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		callOpts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		done, err := breaker.Allow()
+		if err != nil {
+			circuitBreakerRejected.WithLabelValues(service).Inc()
+			return nil, status.Errorf(codes.Unavailable, "%s: %v", service, err)
+		}
+		// only the stream creation is counted
+		stream, err := streamer(ctx, desc, cc, method, callOpts...)
+		done(!containsCode(failureCodes, status.Code(err)))
+		return stream, err
+	}
+}
+
+func containsCode(s []codes.Code, e codes.Code) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
@@ -3,14 +3,28 @@ package grpcutils
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/nmtri1912/go-common/pkg/circuitbreaker"
+	"github.com/nmtri1912/go-common/pkg/discovery"
+	grpc_util "github.com/nmtri1912/go-common/pkg/grpc"
+	"github.com/nmtri1912/go-common/pkg/propagation"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health" // register client-side health checking
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
 
 	// grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -19,68 +33,225 @@ import (
 type cleanupFunc func()
 
 type grpcConfig struct {
-	sslEnable              bool
-	target                 string
-	keepAliveTimeInMinutes int
-	clientId               string
-	clientKey              string
+	sslEnable          bool
+	tls                tlsConfig
+	target             string
+	discovery          discoveryConfig
+	loadBalancing      string
+	keepAlive          keepAliveConfig
+	clientId           string
+	clientKey          string
+	healthCheckEnabled bool
+	healthCheckService string
+	retry              retryConfig
+	hedging            hedgingConfig
+	circuitBreaker     circuitBreakerConfig
+}
+
+type retryConfig struct {
+	maxAttempts       int
+	initialBackoff    time.Duration
+	maxBackoff        time.Duration
+	backoffMultiplier float64
+	retryableCodes    []string
+}
+
+type hedgingConfig struct {
+	methods       map[string]bool
+	maxAttempts   int
+	delay         time.Duration
+	nonFatalCodes []string
+}
+
+type circuitBreakerConfig struct {
+	enabled      bool
+	config       circuitbreaker.Config
+	failureCodes []string
+}
+
+type tlsConfig struct {
+	caFile             string
+	serverName         string
+	certFile           string
+	keyFile            string
+	insecureSkipVerify bool
+}
+
+type discoveryConfig struct {
+	resolver  string
+	addresses []string
+	file      string
+	name      string
+	refresh   time.Duration
+}
+
+type keepAliveConfig struct {
+	time                time.Duration
+	timeout             time.Duration
+	permitWithoutStream bool
 }
 
 type grpcCallConfig struct {
-	deadlineSec int
+	deadlineSec          int
+	deadlineSafetyMargin time.Duration
 }
 
 func getGrpcConfig(service string) grpcConfig {
 	return grpcConfig{
-		sslEnable:              viper.GetBool(service + ".ssl-enabled"),
-		target:                 viper.GetString(service + ".target"),
-		keepAliveTimeInMinutes: viper.GetInt(service + ".keep-alive-time-in-minutes"),
-		clientId:               viper.GetString(service + ".client-id"),
-		clientKey:              viper.GetString(service + ".client-key"),
+		sslEnable: viper.GetBool(service + ".ssl-enabled"),
+		tls: tlsConfig{
+			caFile:             viper.GetString(service + ".tls.ca-file"),
+			serverName:         viper.GetString(service + ".tls.server-name"),
+			certFile:           viper.GetString(service + ".tls.cert-file"),
+			keyFile:            viper.GetString(service + ".tls.key-file"),
+			insecureSkipVerify: viper.GetBool(service + ".tls.insecure-skip-verify"),
+		},
+		target:             viper.GetString(service + ".target"),
+		discovery:          getDiscoveryConfig(service),
+		loadBalancing:      viper.GetString(service + ".load-balancing"),
+		keepAlive:          getKeepAliveConfig(service),
+		clientId:           viper.GetString(service + ".client-id"),
+		clientKey:          viper.GetString(service + ".client-key"),
+		healthCheckEnabled: !viper.IsSet(service+".health-check.enabled") || viper.GetBool(service+".health-check.enabled"),
+		healthCheckService: viper.GetString(service + ".health-check.service"),
+		retry:              getRetryConfig(service),
+		hedging:            getHedgingConfig(service),
+		circuitBreaker:     getCircuitBreakerConfig(service),
 	}
 }
 
-func getGrpcCallConfig(service string) grpcCallConfig {
-	return grpcCallConfig{
-		deadlineSec: viper.GetInt(service + ".deadline-sec"),
+func getRetryConfig(service string) retryConfig {
+	return retryConfig{
+		maxAttempts:       viper.GetInt(service + ".retry.max-attempts"),
+		initialBackoff:    time.Duration(getIntOrDefault(service+".retry.initial-backoff-ms", 100)) * time.Millisecond,
+		maxBackoff:        time.Duration(getIntOrDefault(service+".retry.max-backoff-ms", 1000)) * time.Millisecond,
+		backoffMultiplier: getFloatOrDefault(service+".retry.backoff-multiplier", 2),
+		retryableCodes:    getStringSliceOrDefault(service+".retry.retryable-codes", []string{"UNAVAILABLE"}),
 	}
 }
 
-func CreateConnection(service string) (*grpc.ClientConn, cleanupFunc) {
-	var credential grpc.DialOption
-	grpcConfig := getGrpcConfig(service)
+func getHedgingConfig(service string) hedgingConfig {
+	methods := map[string]bool{}
+	for _, method := range viper.GetStringSlice(service + ".hedging.methods") {
+		methods[strings.ToLower(method)] = true
+	}
+	return hedgingConfig{
+		methods:       methods,
+		maxAttempts:   getIntOrDefault(service+".hedging.max-attempts", 2),
+		delay:         time.Duration(getIntOrDefault(service+".hedging.delay-ms", 100)) * time.Millisecond,
+		nonFatalCodes: getStringSliceOrDefault(service+".hedging.non-fatal-codes", []string{"UNAVAILABLE"}),
+	}
+}
+
+func getCircuitBreakerConfig(service string) circuitBreakerConfig {
+	return circuitBreakerConfig{
+		enabled: viper.GetBool(service + ".circuit-breaker.enabled"),
+		config: circuitbreaker.Config{
+			Window:              time.Duration(getIntOrDefault(service+".circuit-breaker.window-sec", 10)) * time.Second,
+			MinRequests:         getIntOrDefault(service+".circuit-breaker.min-requests", 20),
+			FailureRatio:        getFloatOrDefault(service+".circuit-breaker.failure-ratio", 0.5),
+			OpenTimeout:         time.Duration(getIntOrDefault(service+".circuit-breaker.open-timeout-ms", 5000)) * time.Millisecond,
+			HalfOpenMaxRequests: getIntOrDefault(service+".circuit-breaker.half-open-max-requests", 1),
+		},
+		failureCodes: getStringSliceOrDefault(service+".circuit-breaker.failure-codes",
+			[]string{"UNAVAILABLE", "DEADLINE_EXCEEDED", "INTERNAL", "UNKNOWN", "RESOURCE_EXHAUSTED"}),
+	}
+}
 
-	if grpcConfig.sslEnable { // #nosec G402
-		credential = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}))
-	} else {
-		credential = grpc.WithTransportCredentials(insecure.NewCredentials())
+func getIntOrDefault(key string, defaultValue int) int {
+	if viper.IsSet(key) {
+		return viper.GetInt(key)
 	}
-	grpc.WithKeepaliveParams(keepalive.ClientParameters{})
+	return defaultValue
+}
+
+func getFloatOrDefault(key string, defaultValue float64) float64 {
+	if viper.IsSet(key) {
+		return viper.GetFloat64(key)
+	}
+	return defaultValue
+}
+
+func getStringSliceOrDefault(key string, defaultValue []string) []string {
+	if viper.IsSet(key) {
+		return viper.GetStringSlice(key)
+	}
+	return defaultValue
+}
 
+// parseCodes parses status code names, e.g. UNAVAILABLE
+func parseCodes(names []string) ([]codes.Code, error) {
+	result := make([]codes.Code, 0, len(names))
+	for _, name := range names {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(`"` + codeName(name) + `"`)); err != nil {
+			return nil, err
+		}
+		result = append(result, code)
+	}
+	return result, nil
+}
+
+// codeName returns the canonical name of a status code in config, as expected by the service config, e.g. UNAVAILABLE
+func codeName(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+// serviceConfigCodes validates the status code names and returns their canonical names
+func serviceConfigCodes(names []string) ([]string, error) {
+	if _, err := parseCodes(names); err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		result = append(result, codeName(name))
+	}
+	return result, nil
+}
+
+func getDiscoveryConfig(service string) discoveryConfig {
+	name := viper.GetString(service + ".discovery.name")
+	if len(name) == 0 {
+		name = service
+	}
+	return discoveryConfig{
+		resolver:  viper.GetString(service + ".discovery.resolver"),
+		addresses: viper.GetStringSlice(service + ".discovery.addresses"),
+		file:      viper.GetString(service + ".discovery.file"),
+		name:      name,
+		refresh:   discovery.RefreshInterval(service),
+	}
+}
+
+func getKeepAliveConfig(service string) keepAliveConfig {
+	keepAliveTime := time.Duration(viper.GetInt(service+".keepalive.time-sec")) * time.Second
+	if keepAliveTime <= 0 {
+		// deprecated key
+		keepAliveTime = time.Duration(viper.GetInt(service+".keep-alive-time-in-minutes")) * time.Minute
+	}
+	return keepAliveConfig{
+		time:                keepAliveTime,
+		timeout:             time.Duration(viper.GetInt(service+".keepalive.timeout-sec")) * time.Second,
+		permitWithoutStream: viper.GetBool(service + ".keepalive.permit-without-stream"),
+	}
+}
+
+func getGrpcCallConfig(service string) grpcCallConfig {
+	return grpcCallConfig{
+		deadlineSec:          viper.GetInt(service + ".deadline-sec"),
+		deadlineSafetyMargin: time.Duration(viper.GetInt(service+".deadline-safety-margin-ms")) * time.Millisecond,
+	}
+}
+
+// CreateConnection dials service and blocks until the connection is ready or grpc.connect-timeout-sec elapses,
+// the process exits when the dial fails.
+//
+// Deprecated: use NewConnection or the modulefx/grpcclient module instead
+func CreateConnection(service string) (*grpc.ClientConn, cleanupFunc) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(viper.GetInt("grpc.connect-timeout-sec"))*time.Second)
 	defer cancel()
 
-	// grpc_prometheus.EnableClientHandlingTimeHistogram(grpc_prometheus.WithHistogramBuckets(prometheusutils.ReqDurBuckets))
-	conn, err := grpc.DialContext(
-		ctx,
-		grpcConfig.target,
-		credential,
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Timeout: time.Duration(grpcConfig.keepAliveTimeInMinutes) * time.Minute,
-		}),
-		grpc.WithChainUnaryInterceptor(
-			otelgrpc.UnaryClientInterceptor(),
-			NewAuthenticatorUnaryInterceptor(grpcConfig.clientId, grpcConfig.clientKey),
-			// grpc_prometheus.UnaryClientInterceptor,
-		),
-		grpc.WithChainStreamInterceptor(
-			otelgrpc.StreamClientInterceptor(),
-			NewAuthenticatorStreamInterceptor(grpcConfig.clientId, grpcConfig.clientKey),
-			// grpc_prometheus.StreamClientInterceptor,
-		),
-		grpc.WithBlock(),
-	)
-
+	conn, err := NewConnection(ctx, service, grpc.WithBlock())
 	if err != nil {
 		log.Fatalf("Fail to dial %v: %v", service, err)
 	}
@@ -95,8 +266,213 @@ func CreateConnection(service string) (*grpc.ClientConn, cleanupFunc) {
 	return conn, cleanup
 }
 
+// NewConnection creates a connection to service from the <service>.* config. The dial is not blocking
+// unless grpc.WithBlock() is given in opts. With the redis resolver, the resolver must be given in opts:
+// grpc.WithResolvers(discovery.NewRedisBuilder(registry, interval)), otherwise the dial fails
+func NewConnection(ctx context.Context, service string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	grpcConfig := getGrpcConfig(service)
+
+	target, resolverOptions, err := getTarget(grpcConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery config of %v: %w", service, err)
+	}
+
+	credential, err := getTransportCredentials(grpcConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tls config of %v: %w", service, err)
+	}
+
+	serviceConfig, err := getServiceConfig(grpcConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service config of %v: %w", service, err)
+	}
+
+	resilienceUnaryInterceptors, resilienceStreamInterceptors, err := getResilienceInterceptors(service, grpcConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resilience config of %v: %w", service, err)
+	}
+
+	// grpc_prometheus.EnableClientHandlingTimeHistogram(grpc_prometheus.WithHistogramBuckets(prometheusutils.ReqDurBuckets))
+	unaryInterceptors := []grpc.UnaryClientInterceptor{
+		otelgrpc.UnaryClientInterceptor(),
+		propagation.NewUnaryClientInterceptor(),
+		// grpc_prometheus.UnaryClientInterceptor,
+	}
+	unaryInterceptors = append(unaryInterceptors, resilienceUnaryInterceptors...)
+	streamInterceptors := []grpc.StreamClientInterceptor{
+		otelgrpc.StreamClientInterceptor(),
+		propagation.NewStreamClientInterceptor(),
+		// grpc_prometheus.StreamClientInterceptor,
+	}
+	streamInterceptors = append(streamInterceptors, resilienceStreamInterceptors...)
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(credential),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithChainUnaryInterceptor(unaryInterceptors...),
+		grpc.WithChainStreamInterceptor(streamInterceptors...),
+	}
+	if len(grpcConfig.clientId) > 0 {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(
+			grpc_util.NewClientKeyCredentials(grpcConfig.clientId, grpcConfig.clientKey, false)))
+	}
+	if grpcConfig.keepAlive.time > 0 {
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                grpcConfig.keepAlive.time,
+			Timeout:             grpcConfig.keepAlive.timeout,
+			PermitWithoutStream: grpcConfig.keepAlive.permitWithoutStream,
+		}))
+	}
+	// grpc uses the first resolver of the scheme, the resolvers of opts take precedence
+	dialOptions = append(dialOptions, opts...)
+	dialOptions = append(dialOptions, resolverOptions...)
+
+	return grpc.DialContext(ctx, target, dialOptions...)
+}
+
+func getTransportCredentials(grpcConfig grpcConfig) (credentials.TransportCredentials, error) {
+	if !grpcConfig.sslEnable {
+		return insecure.NewCredentials(), nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         grpcConfig.tls.serverName,
+		InsecureSkipVerify: grpcConfig.tls.insecureSkipVerify, // #nosec G402 only when explicitly configured
+		MinVersion:         tls.VersionTLS12,
+	}
+	if len(grpcConfig.tls.caFile) > 0 {
+		ca, err := os.ReadFile(grpcConfig.tls.caFile)
+		if err != nil {
+			return nil, err
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %s", grpcConfig.tls.caFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	// client certificate for mTLS
+	if len(grpcConfig.tls.certFile) > 0 || len(grpcConfig.tls.keyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(grpcConfig.tls.certFile, grpcConfig.tls.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// getTarget returns the dial target of the configured resolver:
+//   - static: fixed list of <service>.discovery.addresses
+//   - dns: <service>.target, re-resolved every <service>.discovery.refresh-sec
+//   - file: addresses listed in <service>.discovery.file, reloaded on change
+//   - redis: instances of <service>.discovery.name registered in Redis by grpcserver
+//   - empty: <service>.target as is
+func getTarget(grpcConfig grpcConfig) (string, []grpc.DialOption, error) {
+	switch grpcConfig.discovery.resolver {
+	case "":
+		return grpcConfig.target, nil, nil
+	case "static":
+		target := discovery.StaticScheme + ":///" + strings.Join(grpcConfig.discovery.addresses, ",")
+		return target, []grpc.DialOption{grpc.WithResolvers(discovery.NewStaticBuilder())}, nil
+	case "dns":
+		target := discovery.DNSScheme + ":///" + grpcConfig.target
+		return target, []grpc.DialOption{grpc.WithResolvers(discovery.NewDNSBuilder(grpcConfig.discovery.refresh))}, nil
+	case "file":
+		path, err := filepath.Abs(grpcConfig.discovery.file)
+		if err != nil {
+			return "", nil, err
+		}
+		target := discovery.FileScheme + "://" + filepath.ToSlash(path)
+		return target, []grpc.DialOption{grpc.WithResolvers(discovery.NewFileBuilder())}, nil
+	case "redis":
+		return discovery.RedisScheme + ":///" + grpcConfig.discovery.name, []grpc.DialOption{grpc.WithResolvers(missingRegistryBuilder{})}, nil
+	}
+	return "", nil, fmt.Errorf("unknown resolver %s", grpcConfig.discovery.resolver)
+}
+
+var errMissingRegistry = errors.New("the redis resolver requires grpc.WithResolvers(discovery.NewRedisBuilder(registry, interval)) in the dial options")
+
+// missingRegistryBuilder fails the dial of a redis target when no redis resolver is given in the dial options
+type missingRegistryBuilder struct{}
+
+func (missingRegistryBuilder) Build(resolver.Target, resolver.ClientConn, resolver.BuildOptions) (resolver.Resolver, error) {
+	return nil, errMissingRegistry
+}
+
+func (missingRegistryBuilder) Scheme() string {
+	return discovery.RedisScheme
+}
+
+// getServiceConfig builds the default service config. The load balancing policy is round_robin by default.
+// With client-side health checking, backends reporting NOT_SERVING on the health service are removed
+// by round_robin and weighted balancers (pick_first ignores health checking)
+func getServiceConfig(grpcConfig grpcConfig) (string, error) {
+	loadBalancing := grpcConfig.loadBalancing
+	if len(loadBalancing) == 0 {
+		loadBalancing = "round_robin"
+	}
+	serviceConfig := map[string]interface{}{
+		"loadBalancingConfig": []map[string]interface{}{{loadBalancing: map[string]interface{}{}}},
+	}
+	if grpcConfig.healthCheckEnabled {
+		serviceConfig["healthCheckConfig"] = map[string]interface{}{"serviceName": grpcConfig.healthCheckService}
+	}
+	// retries are done by grpc, see https://github.com/grpc/proposal/blob/master/A6-client-retries.md
+	if grpcConfig.retry.maxAttempts > 1 {
+		retryableCodes, err := serviceConfigCodes(grpcConfig.retry.retryableCodes)
+		if err != nil {
+			return "", fmt.Errorf("invalid retryable-codes: %w", err)
+		}
+		serviceConfig["methodConfig"] = []map[string]interface{}{{
+			"name": []map[string]interface{}{{}},
+			"retryPolicy": map[string]interface{}{
+				"maxAttempts":          grpcConfig.retry.maxAttempts,
+				"initialBackoff":       fmt.Sprintf("%.3fs", grpcConfig.retry.initialBackoff.Seconds()),
+				"maxBackoff":           fmt.Sprintf("%.3fs", grpcConfig.retry.maxBackoff.Seconds()),
+				"backoffMultiplier":    grpcConfig.retry.backoffMultiplier,
+				"retryableStatusCodes": retryableCodes,
+			},
+		}}
+	}
+	jsonConfig, err := json.Marshal(serviceConfig)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonConfig), nil
+}
+
+// getResilienceInterceptors returns the circuit breaker and hedging interceptors, when configured
+func getResilienceInterceptors(service string, grpcConfig grpcConfig) ([]grpc.UnaryClientInterceptor, []grpc.StreamClientInterceptor, error) {
+	var unaryInterceptors []grpc.UnaryClientInterceptor
+	var streamInterceptors []grpc.StreamClientInterceptor
+	if grpcConfig.circuitBreaker.enabled {
+		failureCodes, err := parseCodes(grpcConfig.circuitBreaker.failureCodes)
+		if err != nil {
+			return nil, nil, err
+		}
+		breaker := NewCircuitBreaker(service, grpcConfig.circuitBreaker.config)
+		unaryInterceptors = append(unaryInterceptors, NewCircuitBreakerUnaryInterceptor(breaker, service, failureCodes))
+		streamInterceptors = append(streamInterceptors, NewCircuitBreakerStreamInterceptor(breaker, service, failureCodes))
+	}
+	if len(grpcConfig.hedging.methods) > 0 {
+		nonFatalCodes, err := parseCodes(grpcConfig.hedging.nonFatalCodes)
+		if err != nil {
+			return nil, nil, err
+		}
+		unaryInterceptors = append(unaryInterceptors, NewHedgingUnaryInterceptor(
+			grpcConfig.hedging.methods, grpcConfig.hedging.maxAttempts, grpcConfig.hedging.delay, nonFatalCodes))
+	}
+	return unaryInterceptors, streamInterceptors, nil
+}
+
+// GetGrpcCallContext returns a context for an outbound call to service, with a timeout of
+// min(remaining inbound deadline - safety margin, configured deadline)
 func GetGrpcCallContext(ctx context.Context, service string) (context.Context, context.CancelFunc) {
 	grpcCallConfig := getGrpcCallConfig(service)
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(grpcCallConfig.deadlineSec)*time.Second)
+	timeout, ok := grpc_util.DeadlineBudget(ctx, time.Duration(grpcCallConfig.deadlineSec)*time.Second, grpcCallConfig.deadlineSafetyMargin)
+	if !ok {
+		grpc_util.ReportDeadlineBudgetExhausted(ctx, grpc_util.DeadlineSideClient, service)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	return ctx, cancel
 }
@@ -0,0 +1,63 @@
+package grpcutils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/nmtri1912/go-common/pkg/discovery"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestNewConnectionRedisResolver(t *testing.T) {
+	viper.Set("registry-client.discovery.resolver", "redis")
+	t.Cleanup(func() { viper.Set("registry-client.discovery.resolver", "") })
+
+	_, err := NewConnection(context.Background(), "registry-client")
+	if err == nil || !strings.Contains(err.Error(), errMissingRegistry.Error()) {
+		t.Fatalf("NewConnection() without the redis resolver = %v, want %v", err, errMissingRegistry)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	registry := discovery.NewRegistry(client, time.Minute)
+	conn, err := NewConnection(context.Background(), "registry-client",
+		grpc.WithResolvers(discovery.NewRedisBuilder(registry, time.Minute)))
+	if err != nil {
+		t.Fatalf("NewConnection() with the redis resolver: %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestGetServiceConfigRetryableCodes(t *testing.T) {
+	grpcConfig := grpcConfig{retry: retryConfig{
+		maxAttempts:       3,
+		initialBackoff:    100 * time.Millisecond,
+		maxBackoff:        time.Second,
+		backoffMultiplier: 2,
+		retryableCodes:    []string{"unavailable", " Resource_Exhausted"},
+	}}
+	serviceConfig, err := getServiceConfig(grpcConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(serviceConfig, `"retryableStatusCodes":["UNAVAILABLE","RESOURCE_EXHAUSTED"]`) {
+		t.Errorf("service config = %s, want the canonical code names", serviceConfig)
+	}
+	// the service config is validated by the dial
+	conn, err := grpc.Dial("localhost:0", grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithDefaultServiceConfig(serviceConfig))
+	if err != nil {
+		t.Fatalf("Dial() with the service config: %v", err)
+	}
+	_ = conn.Close()
+
+	grpcConfig.retry.retryableCodes = []string{"UNAVAILABLE", "TIMEOUT"}
+	if _, err := getServiceConfig(grpcConfig); err == nil {
+		t.Error("getServiceConfig() with an unknown code should fail")
+	}
+}
@@ -0,0 +1,89 @@
+package grpcutils
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type hedgingResult struct {
+	reply proto.Message
+	err   error
+}
+
+// NewHedgingUnaryInterceptor sends up to maxAttempts copies of a call to the given idempotent methods
+// (lower case full method names). A new copy is sent every delay while no response is received,
+// or immediately when a copy fails with one of nonFatalCodes. The first success or fatal error wins,
+// the other copies are cancelled
+func NewHedgingUnaryInterceptor(methods map[string]bool, maxAttempts int, delay time.Duration, nonFatalCodes []codes.Code) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		callOpts ...grpc.CallOption,
+	) error {
+		replyMessage, ok := reply.(proto.Message)
+		if !ok || maxAttempts <= 1 || !methods[strings.ToLower(method)] {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		results := make(chan hedgingResult, maxAttempts)
+		attempt := func() {
+			attemptReply := replyMessage.ProtoReflect().New().Interface()
+			err := invoker(ctx, method, req, attemptReply, cc, callOpts...)
+			results <- hedgingResult{reply: attemptReply, err: err}
+		}
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		go attempt()
+		started, received := 1, 0
+		var lastErr error
+		for received < started {
+			select {
+			case <-timer.C:
+				if started < maxAttempts {
+					go attempt()
+					started++
+					timer.Reset(delay)
+				}
+			case result := <-results:
+				received++
+				if result.err == nil {
+					proto.Reset(replyMessage)
+					proto.Merge(replyMessage, result.reply)
+					return nil
+				}
+				if !containsCode(nonFatalCodes, status.Code(result.err)) {
+					return result.err
+				}
+				lastErr = result.err
+				if started < maxAttempts {
+					go attempt()
+					started++
+					resetTimer(timer, delay)
+				}
+			}
+		}
+		return lastErr
+	}
+}
+
+func resetTimer(timer *time.Timer, delay time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(delay)
+}
@@ -0,0 +1,128 @@
+package grpcutils
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const hedgedMethod = "/pkg.service/get"
+
+func TestHedgingCancelsSlowAttempt(t *testing.T) {
+	interceptor := NewHedgingUnaryInterceptor(map[string]bool{hedgedMethod: true}, 3, 10*time.Millisecond, nil)
+	var attempts int32
+	var cancelled sync.WaitGroup
+	cancelled.Add(1)
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-ctx.Done()
+			cancelled.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		proto.Merge(reply.(proto.Message), wrapperspb.String("hedged"))
+		return nil
+	}
+
+	reply := &wrapperspb.StringValue{}
+	if err := interceptor(context.Background(), "/pkg.Service/Get", nil, reply, nil, invoker); err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if reply.Value != "hedged" {
+		t.Errorf("reply = %q, want the reply of the hedged attempt", reply.Value)
+	}
+	waitOrFail(t, &cancelled, "the slow attempt must be cancelled")
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestHedgingRetriesNonFatalCodesImmediately(t *testing.T) {
+	interceptor := NewHedgingUnaryInterceptor(map[string]bool{hedgedMethod: true}, 3, time.Hour, []codes.Code{codes.Unavailable})
+	var attempts int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return status.Error(codes.Unavailable, "unavailable")
+		}
+		proto.Merge(reply.(proto.Message), wrapperspb.String("ok"))
+		return nil
+	}
+
+	reply := &wrapperspb.StringValue{}
+	if err := interceptor(context.Background(), "/pkg.Service/Get", nil, reply, nil, invoker); err != nil || reply.Value != "ok" {
+		t.Fatalf("reply = %q, err = %v, want ok", reply.Value, err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestHedgingReturnsFatalErrorAndCancelsOthers(t *testing.T) {
+	interceptor := NewHedgingUnaryInterceptor(map[string]bool{hedgedMethod: true}, 2, 5*time.Millisecond, []codes.Code{codes.Unavailable})
+	var attempts int32
+	var cancelled sync.WaitGroup
+	cancelled.Add(1)
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-ctx.Done()
+			cancelled.Done()
+			return ctx.Err()
+		}
+		return status.Error(codes.InvalidArgument, "invalid")
+	}
+
+	err := interceptor(context.Background(), "/pkg.Service/Get", nil, &wrapperspb.StringValue{}, nil, invoker)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("err = %v, want InvalidArgument", err)
+	}
+	waitOrFail(t, &cancelled, "the other attempt must be cancelled")
+}
+
+func TestHedgingLastNonFatalError(t *testing.T) {
+	interceptor := NewHedgingUnaryInterceptor(map[string]bool{hedgedMethod: true}, 2, time.Hour, []codes.Code{codes.Unavailable})
+	var attempts int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		atomic.AddInt32(&attempts, 1)
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	err := interceptor(context.Background(), "/pkg.Service/Get", nil, &wrapperspb.StringValue{}, nil, invoker)
+	if status.Code(err) != codes.Unavailable || atomic.LoadInt32(&attempts) != 2 {
+		t.Errorf("err = %v after %d attempts, want Unavailable after 2", err, attempts)
+	}
+}
+
+func TestHedgingSkipsOtherMethods(t *testing.T) {
+	interceptor := NewHedgingUnaryInterceptor(map[string]bool{hedgedMethod: true}, 3, time.Millisecond, []codes.Code{codes.Unavailable})
+	var attempts int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		atomic.AddInt32(&attempts, 1)
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	_ = interceptor(context.Background(), "/pkg.Service/Create", nil, &wrapperspb.StringValue{}, nil, invoker)
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("attempts = %d, want 1 for a method that is not hedged", got)
+	}
+}
+
+func waitOrFail(t *testing.T, wg *sync.WaitGroup, message string) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal(message)
+	}
+}
@@ -3,10 +3,14 @@ package grpcutils
 import (
 	"context"
 
+	grpc_util "github.com/nmtri1912/go-common/pkg/grpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
 
+// NewAuthenticatorUnaryInterceptor adds client-id and client-key to the outgoing metadata.
+//
+// Deprecated: use grpc.WithPerRPCCredentials(grpc_util.NewClientKeyCredentials(clientId, clientKey, false))
 func NewAuthenticatorUnaryInterceptor(clientId, clientKey string) grpc.UnaryClientInterceptor {
 	return func(
 		ctx context.Context,
@@ -16,12 +20,17 @@ func NewAuthenticatorUnaryInterceptor(clientId, clientKey string) grpc.UnaryClie
 		invoker grpc.UnaryInvoker,
 		callOpts ...grpc.CallOption,
 	) error {
-		newCtx := metadata.AppendToOutgoingContext(ctx, "client-id", clientId)
-		newCtx = metadata.AppendToOutgoingContext(newCtx, "client-key", clientKey)
+		newCtx := metadata.AppendToOutgoingContext(ctx,
+			grpc_util.ClientIdMetadataKey, clientId,
+			grpc_util.ClientKeyMetadataKey, clientKey,
+		)
 		return invoker(newCtx, method, req, reply, cc, callOpts...)
 	}
 }
 
+// NewAuthenticatorStreamInterceptor adds client-id and client-key to the outgoing metadata.
+//
+// Deprecated: use grpc.WithPerRPCCredentials(grpc_util.NewClientKeyCredentials(clientId, clientKey, false))
 func NewAuthenticatorStreamInterceptor(clientId, clientKey string) grpc.StreamClientInterceptor {
 	return func(
 		ctx context.Context,
@@ -31,8 +40,10 @@ func NewAuthenticatorStreamInterceptor(clientId, clientKey string) grpc.StreamCl
 		streamer grpc.Streamer,
 		callOpts ...grpc.CallOption,
 	) (grpc.ClientStream, error) {
-		newCtx := metadata.AppendToOutgoingContext(ctx, "client-id", clientId)
-		newCtx = metadata.AppendToOutgoingContext(newCtx, "client-key", clientKey)
+		newCtx := metadata.AppendToOutgoingContext(ctx,
+			grpc_util.ClientIdMetadataKey, clientId,
+			grpc_util.ClientKeyMetadataKey, clientKey,
+		)
 		return streamer(newCtx, desc, cc, method, callOpts...)
 	}
 }
@@ -4,10 +4,20 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nmtri1912/go-common/pkg/health"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
 )
 
+// NewMuxServer serves the metrics of prometheus.DefaultGatherer on /metrics
 func NewMuxServer(r *gin.Engine) *http.ServeMux {
+	return NewMuxServerWithGatherer(r, prometheus.DefaultGatherer)
+}
+
+// NewMuxServerWithGatherer serves the metrics of gatherer on /metrics
+func NewMuxServerWithGatherer(r *gin.Engine, gatherer prometheus.Gatherer) *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -15,8 +25,17 @@ func NewMuxServer(r *gin.Engine) *http.ServeMux {
 	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	mux.HandleFunc("/ready", health.HTTPHandler(health.DefaultChecker, health.Timeout()))
+	if viper.GetBool("logger.level-endpoint") {
+		mux.HandleFunc("/log/level", logger.LevelHandler(logger.LevelTTL()))
+	}
+	// OpenMetrics exposes the exemplars to scrapers requesting it
+	metricsHandler := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})
+	if gatherer == prometheus.DefaultGatherer {
+		metricsHandler = promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, metricsHandler)
+	}
 	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
-		promhttp.Handler().ServeHTTP(w, r)
+		metricsHandler.ServeHTTP(w, r)
 	})
 	if r != nil {
 		mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,36 @@
+package httputils
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nmtri1912/go-common/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Recovery replaces gin.Recovery: the panic is logged with its stack trace, so that it is
+// reported by the error hooks, and the request is aborted with 500
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if r == http.ErrAbortHandler {
+				// aborts the response on purpose, net/http handles it silently
+				panic(r)
+			}
+			logger.Ctx(c.Request.Context()).Error("Recovered panic: ",
+				zap.String("method", c.Request.Method), zap.String("path", c.FullPath()),
+				zap.Any(logger.FieldPanic, r), zap.String(logger.FieldStack, string(debug.Stack())))
+			if !c.Writer.Written() {
+				c.AbortWithStatus(http.StatusInternalServerError)
+			} else {
+				c.Abort()
+			}
+		}()
+		c.Next()
+	}
+}
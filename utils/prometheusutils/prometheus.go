@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nmtri1912/go-common/pkg/metrics"
+	"github.com/nmtri1912/go-common/pkg/monitor"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -21,18 +23,9 @@ const (
 	TB
 )
 
-// reqDurBuckets is the buckets for request duration. Here, we use the prometheus defaults
-// which are for ~10s request length max: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
-var ReqDurBuckets = []float64{0.001, 0.001048576, 0.001398101, 0.001747626, 0.002097151, 0.002446676,
-	0.002796201, 0.003145726, 0.003495251, 0.003844776, 0.004194304, 0.005592405, 0.006990506,
-	0.008388607, 0.009786708, 0.011184809, 0.01258291, 0.013981011, 0.015379112, 0.016777216,
-	0.022369621, 0.027962026, 0.033554431, 0.039146836, 0.044739241, 0.050331646, 0.055924051,
-	0.061516456, 0.067108864, 0.089478485, 0.111848106, 0.134217727, 0.156587348, 0.178956969,
-	0.20132659, 0.223696211, 0.246065832, 0.268435456, 0.357913941, 0.447392426, 0.536870911,
-	0.626349396, 0.715827881, 0.805306366, 0.894784851, 0.984263336, 1.073741824, 1.431655765,
-	1.789569706, 2.147483647, 2.505397588, 2.863311529, 3.22122547, 3.579139411, 3.937053352,
-	4.294967296, 5.726623061, 7.158278826, 8.589934591, 10.021590356, 11.453246121, 12.884901886,
-	14.316557651, 15.748213416, 17.179869184, 22.906492245, 28.633115306, 30}
+// ReqDurBuckets is the default buckets for request duration, a copy of the default buckets of monitor.
+// Configure them with monitor.histograms.http
+var ReqDurBuckets = append([]float64(nil), monitor.GetHistorgramBuckets()...)
 
 // reqSzBuckets is the buckets for request size. Here we define a spectrom from 1KB thru 1NB up to 10MB.
 var ReqSzBuckets = []float64{1.0 * KB, 2.0 * KB, 5.0 * KB, 10.0 * KB, 100 * KB, 500 * KB, 1.0 * MB, 2.5 * MB, 5.0 * MB, 10.0 * MB}
@@ -78,6 +71,26 @@ var reqSz = &Metric{
 	Buckets:     ReqSzBuckets,
 }
 
+// guardedLabels are the labels of the standard metrics limited to monitor.LabelLimit distinct values,
+// they come from the clients
+var guardedLabels = []string{"method", "host", "url"}
+
+// newLabelLimiter limits the guardedLabels of a metric, nothing when it has none of them
+func newLabelLimiter(metricDef *Metric) *metrics.LabelLimiter {
+	var guarded []string
+	for _, label := range guardedLabels {
+		for _, arg := range metricDef.Args {
+			if arg == label {
+				guarded = append(guarded, label)
+			}
+		}
+	}
+	if len(guarded) == 0 {
+		return metrics.NewLabelLimiter(metricDef.Name, metricDef.Args, 0)
+	}
+	return monitor.NewLabelLimiter(metricDef.Name, metricDef.Args, guarded...)
+}
+
 var standardMetrics = []*Metric{
 	reqCnt,
 	reqDur,
@@ -88,8 +101,8 @@ var standardMetrics = []*Metric{
 /*
 RequestCounterLabelMappingFunc is a function which can be supplied to the middleware to control
 the cardinality of the request counter's "url" label, which might be required in some contexts.
-For instance, if for a "/customer/:name" route you don't want to generate a time series for every
-possible customer name, you could use this function:
+The default one returns the route template of gin, c.FullPath(). For instance, to use the
+path of the request with the template of the "name" parameter only, you could use this function:
 func(c echo.Context) string {
 	url := c.Request.URL.Path
 	for _, p := range c.Params {
@@ -127,14 +140,16 @@ type Metric struct {
 	Type            string
 	Args            []string
 	Buckets         []float64
+	// NativeHistogram enables the native histogram of histogram types
+	NativeHistogram *monitor.NativeHistogramOpts
 }
 
 /*
 Prometheus contains the metrics gathered by the instance and its path
 */
 type Prometheus struct {
-	reqCnt               *prometheus.CounterVec
-	reqDur, reqSz, resSz *prometheus.HistogramVec
+	reqCnt               *metrics.LimitedVec[prometheus.Counter]
+	reqDur, reqSz, resSz *metrics.LimitedVec[prometheus.Observer]
 
 	MetricsList []*Metric
 	MetricsPath string
@@ -149,9 +164,18 @@ type Prometheus struct {
 }
 
 /*
-NewPrometheus generates a new set of metrics with a certain subsystem name
+NewPrometheus generates a new set of metrics with a certain subsystem name,
+registered in prometheus.DefaultRegisterer
 */
 func NewPrometheus(subsystem string, skipper Skipper, customMetricsList ...[]*Metric) *Prometheus {
+	return NewPrometheusWithRegisterer(prometheus.DefaultRegisterer, subsystem, skipper, customMetricsList...)
+}
+
+/*
+NewPrometheusWithRegisterer generates a new set of metrics with a certain subsystem name,
+registered in registerer. Metrics already registered in registerer are reused
+*/
+func NewPrometheusWithRegisterer(registerer prometheus.Registerer, subsystem string, skipper Skipper, customMetricsList ...[]*Metric) *Prometheus {
 	var metricsList []*Metric
 	if skipper == nil {
 		skipper = DefaultSkipper
@@ -163,7 +187,17 @@ func NewPrometheus(subsystem string, skipper Skipper, customMetricsList ...[]*Me
 		metricsList = customMetricsList[0]
 	}
 
-	metricsList = append(metricsList, standardMetrics...)
+	// the request duration buckets are read from monitor.histograms.http
+	durationSettings := monitor.LoadHistogramSettings("http", ReqDurBuckets)
+	configuredReqDur := *reqDur
+	configuredReqDur.Buckets = durationSettings.Buckets
+	configuredReqDur.NativeHistogram = durationSettings.Native
+	for _, metricDef := range standardMetrics {
+		if metricDef == reqDur {
+			metricDef = &configuredReqDur
+		}
+		metricsList = append(metricsList, metricDef)
+	}
 
 	p := &Prometheus{
 		MetricsList: metricsList,
@@ -171,14 +205,17 @@ func NewPrometheus(subsystem string, skipper Skipper, customMetricsList ...[]*Me
 		Subsystem:   defaultSubsystem,
 		Skipper:     skipper,
 		RequestCounterURLLabelMappingFunc: func(c *gin.Context) string {
-			return c.Request.URL.Path // i.e. by default do nothing, i.e. return URL as is
+			if route := c.FullPath(); len(route) > 0 {
+				return route
+			}
+			return "unknown" // no matching route, e.g. 404
 		},
 		RequestCounterHostLabelMappingFunc: func(c *gin.Context) string {
 			return c.Request.Host
 		},
 	}
 
-	p.registerMetrics(subsystem)
+	p.registerMetrics(registerer, subsystem)
 
 	return p
 }
@@ -224,24 +261,21 @@ func NewMetric(m *Metric, subsystem string) prometheus.Collector {
 			},
 		)
 	case "histogram_vec":
-		metric = prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Subsystem: subsystem,
-				Name:      m.Name,
-				Help:      m.Description,
-				Buckets:   m.Buckets,
-			},
-			m.Args,
-		)
+		opts := prometheus.HistogramOpts{
+			Subsystem: subsystem,
+			Name:      m.Name,
+			Help:      m.Description,
+		}
+		monitor.HistogramSettings{Buckets: m.Buckets, Native: m.NativeHistogram}.Apply(&opts)
+		metric = prometheus.NewHistogramVec(opts, m.Args)
 	case "histogram":
-		metric = prometheus.NewHistogram(
-			prometheus.HistogramOpts{
-				Subsystem: subsystem,
-				Name:      m.Name,
-				Help:      m.Description,
-				Buckets:   m.Buckets,
-			},
-		)
+		opts := prometheus.HistogramOpts{
+			Subsystem: subsystem,
+			Name:      m.Name,
+			Help:      m.Description,
+		}
+		monitor.HistogramSettings{Buckets: m.Buckets, Native: m.NativeHistogram}.Apply(&opts)
+		metric = prometheus.NewHistogram(opts)
 	case "summary_vec":
 		metric = prometheus.NewSummaryVec(
 			prometheus.SummaryOpts{
@@ -263,22 +297,32 @@ func NewMetric(m *Metric, subsystem string) prometheus.Collector {
 	return metric
 }
 
-func (p *Prometheus) registerMetrics(subsystem string) {
+func (p *Prometheus) registerMetrics(registerer prometheus.Registerer, subsystem string) {
 
 	for _, metricDef := range p.MetricsList {
 		metric := NewMetric(metricDef, subsystem)
-		if err := prometheus.Register(metric); err != nil {
-			log.Printf("%s could not be registered in Prometheus: %v", metricDef.Name, err)
-		}
-		switch metricDef {
-		case reqCnt:
-			p.reqCnt = metric.(*prometheus.CounterVec)
-		case reqDur:
-			p.reqDur = metric.(*prometheus.HistogramVec)
-		case resSz:
-			p.resSz = metric.(*prometheus.HistogramVec)
-		case reqSz:
-			p.reqSz = metric.(*prometheus.HistogramVec)
+		limiter := newLabelLimiter(metricDef)
+		switch metricDef.ID {
+		case reqCnt.ID:
+			p.reqCnt = metrics.RegisterLimited[prometheus.Counter](registerer, metric.(*prometheus.CounterVec), limiter)
+			metric = p.reqCnt.Vec().(prometheus.Collector)
+		case reqDur.ID:
+			p.reqDur = metrics.RegisterLimited[prometheus.Observer](registerer, metric.(*prometheus.HistogramVec), limiter)
+			metric = p.reqDur.Vec().(prometheus.Collector)
+		case resSz.ID:
+			p.resSz = metrics.RegisterLimited[prometheus.Observer](registerer, metric.(*prometheus.HistogramVec), limiter)
+			metric = p.resSz.Vec().(prometheus.Collector)
+		case reqSz.ID:
+			p.reqSz = metrics.RegisterLimited[prometheus.Observer](registerer, metric.(*prometheus.HistogramVec), limiter)
+			metric = p.reqSz.Vec().(prometheus.Collector)
+		default:
+			if err := registerer.Register(metric); err != nil {
+				if registered, ok := err.(prometheus.AlreadyRegisteredError); ok {
+					metric = registered.ExistingCollector
+				} else {
+					log.Printf("%s could not be registered in Prometheus: %v", metricDef.Name, err)
+				}
+			}
 		}
 		metricDef.MetricCollector = metric
 	}
@@ -0,0 +1,16 @@
+package prometheusutils
+
+import (
+	"testing"
+
+	"github.com/nmtri1912/go-common/pkg/monitor"
+)
+
+func TestReqDurBucketsCopy(t *testing.T) {
+	previous := ReqDurBuckets[0]
+	ReqDurBuckets[0] = 0.0001
+	t.Cleanup(func() { ReqDurBuckets[0] = previous })
+	if monitor.GetHistorgramBuckets()[0] == 0.0001 {
+		t.Error("changing ReqDurBuckets changes the default buckets of monitor")
+	}
+}